@@ -0,0 +1,128 @@
+// Command reona-bench runs configurable workloads against the structures of this module
+// and reports throughput, latency percentiles and allocations as JSON or CSV.
+//
+// It exercises the same structures as BenchmarkLockFree, but outside of `go test`, so
+// sweeps can be scripted, e.g.
+//
+//	reona-bench -structure map,lockfree-list -threads 1,2,4,8 -mix get=90,insert=10 -format csv
+//
+// With -mode memory it instead fills every structure with -keys entries and reports bytes
+// per entry, allocations per insert, GC pauses while filling and the heap retained after
+// removing everything again, next to the structure's own MemoryUsage estimate.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func main() {
+	if err := realMain(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "reona-bench:", err)
+		os.Exit(1)
+	}
+}
+
+func realMain(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("reona-bench", flag.ContinueOnError)
+	var (
+		mode          = fs.String("mode", "bench", "bench for throughput and latency, memory for memory usage")
+		structureList = fs.String("structure", "map", "comma separated structures, one of "+strings.Join(structureNames(), ", "))
+		threadList    = fs.String("threads", "1", "comma separated worker counts to sweep")
+		keySpace      = fs.Int("keys", 1024, "number of distinct keys")
+		prefill       = fs.Float64("prefill", 0.5, "inserts done before measuring, as a fraction of -keys")
+		buckets       = fs.Uint64("buckets", 64, "bucket count of map")
+		duration      = fs.Duration("duration", time.Second, "measuring time of every run")
+		mixSpec       = fs.String("mix", "get=80,insert=15,remove=5", "operation mix in percent")
+		seed          = fs.Int64("seed", 1, "seed of the key and op generators")
+		format        = fs.String("format", "json", "output format, json or csv")
+		output        = fs.String("o", "", "output file, stdout if empty")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	m, err := parseMix(*mixSpec)
+	if err != nil {
+		return err
+	}
+	threads, err := parseInts(*threadList)
+	if err != nil {
+		return err
+	}
+	if *keySpace <= 0 {
+		return fmt.Errorf("-keys must be positive")
+	}
+	if *buckets == 0 {
+		return fmt.Errorf("-buckets must be positive")
+	}
+	if err := checkFormat(*format); err != nil {
+		return err
+	}
+	if *mode != "bench" && *mode != "memory" {
+		return fmt.Errorf("unknown mode %q, want bench or memory", *mode)
+	}
+
+	var (
+		results    []result
+		memResults []memResult
+	)
+	for _, name := range strings.Split(*structureList, ",") {
+		cfg := config{
+			structure: strings.TrimSpace(name),
+			keySpace:  *keySpace,
+			prefill:   *prefill,
+			buckets:   *buckets,
+			duration:  *duration,
+			mix:       m,
+			seed:      *seed,
+		}
+		if *mode == "memory" {
+			r, err := runMemory(&cfg)
+			if err != nil {
+				return err
+			}
+			memResults = append(memResults, r)
+			continue
+		}
+		for _, n := range threads {
+			cfg.threads = n
+			r, err := run(&cfg)
+			if err != nil {
+				return err
+			}
+			results = append(results, r)
+		}
+	}
+
+	out := stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	if *mode == "memory" {
+		return writeReport(out, *format, memResults)
+	}
+	return writeReport(out, *format, results)
+}
+
+func parseInts(s string) ([]int, error) {
+	var r []int
+	for _, part := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid thread count %q", part)
+		}
+		r = append(r, n)
+	}
+	return r, nil
+}
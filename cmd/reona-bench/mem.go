@@ -0,0 +1,82 @@
+package main
+
+import (
+	"runtime"
+)
+
+// memResult is what -mode memory measures for one structure.
+type memResult struct {
+	Structure string `json:"structure"`
+	Entries   int    `json:"entries"`
+	// BytesPerEntry is the live heap growth after filling, divided by the entries.
+	BytesPerEntry float64 `json:"bytes_per_entry"`
+	// EstimatedBytesPerEntry is what the structure's MemoryUsage claims.
+	EstimatedBytesPerEntry float64 `json:"estimated_bytes_per_entry"`
+	AllocsPerInsert        float64 `json:"allocs_per_insert"`
+	// GC cycles and stop the world pauses that happened while filling.
+	GCCycles       uint32 `json:"gc_cycles"`
+	GCPauseTotalNs uint64 `json:"gc_pause_total_ns"`
+	GCPauseMaxNs   uint64 `json:"gc_pause_max_ns"`
+	// RetainedBytesAfterRemove is the live heap still held once every entry was removed.
+	RetainedBytesAfterRemove  int64  `json:"retained_bytes_after_remove"`
+	EstimatedBytesAfterRemove uint64 `json:"estimated_bytes_after_remove"`
+}
+
+// heapInUse collects garbage twice, so that objects freed by finalizers of the first cycle
+// are gone too, and returns the live heap.
+func heapInUse(ms *runtime.MemStats) uint64 {
+	runtime.GC()
+	runtime.GC()
+	runtime.ReadMemStats(ms)
+	return ms.HeapAlloc
+}
+
+// maxPause returns the longest pause of the GC cycles between two snapshots, as far as
+// they are still in the 256 entry ring of MemStats.PauseNs.
+func maxPause(before, after *runtime.MemStats) uint64 {
+	var r uint64
+	for n := before.NumGC + 1; n <= after.NumGC && after.NumGC-n < uint32(len(after.PauseNs)); n++ {
+		r = max(r, after.PauseNs[(n+uint32(len(after.PauseNs))-1)%uint32(len(after.PauseNs))])
+	}
+	return r
+}
+
+// runMemory fills a fresh structure with cfg.keySpace entries on a single goroutine,
+// then removes all of them again.
+func runMemory(cfg *config) (memResult, error) {
+	s, err := lookupStructure(cfg.structure, 1)
+	if err != nil {
+		return memResult{}, err
+	}
+	var before, filled, live, removed runtime.MemStats
+	base := heapInUse(&before)
+
+	t := s.build(cfg)
+	for i := 0; i < cfg.keySpace; i++ {
+		t.insert(i, i)
+	}
+	runtime.ReadMemStats(&filled)
+	liveHeap := heapInUse(&live)
+	estimate := t.memoryUsage()
+
+	for i := 0; i < cfg.keySpace; i++ {
+		t.remove(i)
+	}
+	retained := heapInUse(&removed)
+	estimateAfter := t.memoryUsage()
+	runtime.KeepAlive(t)
+
+	n := float64(cfg.keySpace)
+	return memResult{
+		Structure:                 cfg.structure,
+		Entries:                   cfg.keySpace,
+		BytesPerEntry:             float64(int64(liveHeap)-int64(base)) / n,
+		EstimatedBytesPerEntry:    float64(estimate) / n,
+		AllocsPerInsert:           float64(filled.Mallocs-before.Mallocs) / n,
+		GCCycles:                  filled.NumGC - before.NumGC,
+		GCPauseTotalNs:            filled.PauseTotalNs - before.PauseTotalNs,
+		GCPauseMaxNs:              maxPause(&before, &filled),
+		RetainedBytesAfterRemove:  int64(retained) - int64(base),
+		EstimatedBytesAfterRemove: estimateAfter,
+	}, nil
+}
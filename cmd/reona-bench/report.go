@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// row is a result that can be reported as a line of CSV.
+type row interface {
+	header() []string
+	fields() []string
+}
+
+func checkFormat(format string) error {
+	if format != "json" && format != "csv" {
+		return fmt.Errorf("unknown format %q, want json or csv", format)
+	}
+	return nil
+}
+
+func writeReport[R row](w io.Writer, format string, rows []R) error {
+	if format == "csv" {
+		return writeCSV(w, rows)
+	}
+	return writeJSON(w, rows)
+}
+
+func writeJSON[R row](w io.Writer, rows []R) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rows)
+}
+
+func writeCSV[R row](w io.Writer, rows []R) error {
+	cw := csv.NewWriter(w)
+	var zero R
+	if err := cw.Write(zero.header()); err != nil {
+		return err
+	}
+	for _, r := range rows {
+		if err := cw.Write(r.fields()); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+var csvHeader = []string{
+	"structure", "threads", "key_space", "mix", "duration_ns", "ops", "ops_per_sec",
+	"p50_ns", "p99_ns", "p999_ns", "allocs_per_op", "bytes_per_op",
+}
+
+func (result) header() []string {
+	return csvHeader
+}
+
+func (r result) fields() []string {
+	return []string{
+		r.Structure,
+		strconv.Itoa(r.Threads),
+		strconv.Itoa(r.KeySpace),
+		r.Mix,
+		strconv.FormatInt(r.DurationNs, 10),
+		strconv.FormatUint(r.Ops, 10),
+		strconv.FormatFloat(r.OpsPerSec, 'f', 1, 64),
+		strconv.FormatInt(r.P50Ns, 10),
+		strconv.FormatInt(r.P99Ns, 10),
+		strconv.FormatInt(r.P999Ns, 10),
+		strconv.FormatFloat(r.AllocsPerOp, 'f', 3, 64),
+		strconv.FormatFloat(r.BytesPerOp, 'f', 1, 64),
+	}
+}
+
+var memCSVHeader = []string{
+	"structure", "entries", "bytes_per_entry", "estimated_bytes_per_entry", "allocs_per_insert",
+	"gc_cycles", "gc_pause_total_ns", "gc_pause_max_ns", "retained_bytes_after_remove",
+	"estimated_bytes_after_remove",
+}
+
+func (memResult) header() []string {
+	return memCSVHeader
+}
+
+func (r memResult) fields() []string {
+	return []string{
+		r.Structure,
+		strconv.Itoa(r.Entries),
+		strconv.FormatFloat(r.BytesPerEntry, 'f', 1, 64),
+		strconv.FormatFloat(r.EstimatedBytesPerEntry, 'f', 1, 64),
+		strconv.FormatFloat(r.AllocsPerInsert, 'f', 3, 64),
+		strconv.FormatUint(uint64(r.GCCycles), 10),
+		strconv.FormatUint(r.GCPauseTotalNs, 10),
+		strconv.FormatUint(r.GCPauseMaxNs, 10),
+		strconv.FormatInt(r.RetainedBytesAfterRemove, 10),
+		strconv.FormatUint(r.EstimatedBytesAfterRemove, 10),
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/crrow/reona/linkedlist"
+	"github.com/crrow/reona/linkedlist/lock"
+	"github.com/crrow/reona/linkedlist/thread_unsafe"
+	"github.com/crrow/reona/skiplist"
+	"github.com/crrow/reona/skiplist/lazy"
+)
+
+// target is the smallest common surface of every structure we benchmark.
+//
+// Queue-like structures have no keyed lookups, so for them insert pushes,
+// get peeks and remove pops, the same mapping BenchmarkLockFree uses.
+type target interface {
+	insert(k, v int)
+	get(k int) bool
+	remove(k int) bool
+	memoryUsage() uint64
+}
+
+type structure struct {
+	// concurrent reports whether the structure may be shared by several workers.
+	concurrent bool
+	build      func(cfg *config) target
+}
+
+var structures = map[string]structure{
+	"lockfree-list": {concurrent: true, build: func(*config) target {
+		return lockFreeList{linkedlist.New[int, int]()}
+	}},
+	"map": {concurrent: true, build: func(cfg *config) target {
+		return lockFreeMap{linkedlist.NewMap[int, int](linkedlist.WithCapacity[int, int](cfg.buckets))}
+	}},
+	"lock-list": {concurrent: true, build: func(*config) target {
+		return lockList{lock.NewLinkedList[int]()}
+	}},
+	"unsafe-list": {concurrent: false, build: func(*config) target {
+		return unsafeList{thread_unsafe.New[int]()}
+	}},
+	"skiplist": {concurrent: true, build: func(*config) target {
+		return skipList{skiplist.New[int, int]()}
+	}},
+	"lazy-skiplist": {concurrent: true, build: func(*config) target {
+		return skipList{lazy.New[int, int]()}
+	}},
+}
+
+func structureNames() []string {
+	names := make([]string, 0, len(structures))
+	for name := range structures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func lookupStructure(name string, threads int) (structure, error) {
+	s, ok := structures[name]
+	if !ok {
+		return structure{}, fmt.Errorf("unknown structure %q, want one of %v", name, structureNames())
+	}
+	if !s.concurrent && threads > 1 {
+		return structure{}, fmt.Errorf("structure %q is not thread safe, run it with -threads 1", name)
+	}
+	return s, nil
+}
+
+type lockFreeList struct {
+	l *linkedlist.LinkedList[int, int]
+}
+
+func (t lockFreeList) insert(k, v int)     { t.l.Insert(k, v) }
+func (t lockFreeList) get(k int) bool      { return t.l.Get(k) != nil }
+func (t lockFreeList) remove(k int) bool   { return t.l.Remove(k) }
+func (t lockFreeList) memoryUsage() uint64 { return t.l.MemoryUsage() }
+
+type lockFreeMap struct{ m *linkedlist.Map[int, int] }
+
+func (t lockFreeMap) insert(k, v int) { t.m.Insert(k, v) }
+func (t lockFreeMap) get(k int) bool {
+	_, ok := t.m.Get(k)
+	return ok
+}
+func (t lockFreeMap) remove(k int) bool   { return t.m.Remove(k) }
+func (t lockFreeMap) memoryUsage() uint64 { return t.m.MemoryUsage() }
+
+type lockList struct{ l *lock.LinkedList[int] }
+
+func (t lockList) insert(_, v int) { t.l.Push(v) }
+func (t lockList) get(int) bool {
+	_, ok := t.l.Peek()
+	return ok
+}
+func (t lockList) remove(int) bool {
+	_, ok := t.l.Pop()
+	return ok
+}
+func (t lockList) memoryUsage() uint64 { return t.l.MemoryUsage() }
+
+type unsafeList struct {
+	l *thread_unsafe.LinkedList[int]
+}
+
+func (t unsafeList) insert(_, v int) { t.l.PushBack(v) }
+func (t unsafeList) get(int) bool    { return t.l.Front() != nil }
+func (t unsafeList) remove(int) bool {
+	if t.l.Len() == 0 {
+		return false
+	}
+	t.l.PopFront()
+	return true
+}
+func (t unsafeList) memoryUsage() uint64 { return t.l.MemoryUsage() }
+
+// skipList adapts every skiplist.Map that can estimate its memory usage.
+type skipList struct {
+	m interface {
+		skiplist.Map[int, int]
+		MemoryUsage() uint64
+	}
+}
+
+func (t skipList) insert(k, v int) { t.m.Insert(k, v) }
+func (t skipList) get(k int) bool {
+	_, ok := t.m.Get(k)
+	return ok
+}
+func (t skipList) remove(k int) bool   { return t.m.Remove(k) }
+func (t skipList) memoryUsage() uint64 { return t.m.MemoryUsage() }
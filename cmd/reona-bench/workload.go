@@ -0,0 +1,219 @@
+package main
+
+import (
+	"fmt"
+	"math/bits"
+	"math/rand"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type config struct {
+	structure string
+	threads   int
+	keySpace  int
+	prefill   float64
+	buckets   uint64
+	duration  time.Duration
+	mix       mix
+	seed      int64
+}
+
+// mix is the percentage of get, insert and remove operations, it always sums to 100.
+type mix struct {
+	get, insert, remove int
+}
+
+func (m mix) String() string {
+	return fmt.Sprintf("get=%d,insert=%d,remove=%d", m.get, m.insert, m.remove)
+}
+
+// parseMix parses "get=80,insert=15,remove=5", missing operations count as 0.
+func parseMix(s string) (mix, error) {
+	var m mix
+	for _, part := range strings.Split(s, ",") {
+		name, val, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return mix{}, fmt.Errorf("invalid op mix entry %q", part)
+		}
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 0 {
+			return mix{}, fmt.Errorf("invalid percentage in %q", part)
+		}
+		switch name {
+		case "get":
+			m.get = n
+		case "insert":
+			m.insert = n
+		case "remove":
+			m.remove = n
+		default:
+			return mix{}, fmt.Errorf("unknown op %q in mix", name)
+		}
+	}
+	if m.get+m.insert+m.remove != 100 {
+		return mix{}, fmt.Errorf("op mix %q does not sum to 100", s)
+	}
+	return m, nil
+}
+
+// histogram is a log-linear latency histogram: every power of two is split into
+// 1<<subBits linear buckets, which keeps the relative error under 1/(1<<subBits)
+// without recording individual samples.
+type histogram struct {
+	counts [64 << subBits]uint64
+	total  uint64
+}
+
+const subBits = 4
+
+func bucketOf(ns uint64) int {
+	if ns < 1<<subBits {
+		return int(ns)
+	}
+	exp := bits.Len64(ns) - subBits - 1
+	return (exp+1)<<subBits | int(ns>>exp)&(1<<subBits-1)
+}
+
+// lowerBound returns the smallest latency that falls into bucket b.
+func lowerBound(b int) uint64 {
+	if b < 1<<subBits {
+		return uint64(b)
+	}
+	exp := b>>subBits - 1
+	return (1<<subBits | uint64(b)&(1<<subBits-1)) << exp
+}
+
+func (h *histogram) record(d time.Duration) {
+	h.counts[bucketOf(uint64(d))]++
+	h.total++
+}
+
+func (h *histogram) merge(o *histogram) {
+	for i, c := range o.counts {
+		h.counts[i] += c
+	}
+	h.total += o.total
+}
+
+// quantile returns the lower bound of the bucket holding the q-th sample.
+func (h *histogram) quantile(q float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	rank := uint64(q * float64(h.total))
+	if rank >= h.total {
+		rank = h.total - 1
+	}
+	var seen uint64
+	for i, c := range h.counts {
+		seen += c
+		if seen > rank {
+			return time.Duration(lowerBound(i))
+		}
+	}
+	return 0
+}
+
+type result struct {
+	Structure   string  `json:"structure"`
+	Threads     int     `json:"threads"`
+	KeySpace    int     `json:"key_space"`
+	Mix         string  `json:"mix"`
+	DurationNs  int64   `json:"duration_ns"`
+	Ops         uint64  `json:"ops"`
+	OpsPerSec   float64 `json:"ops_per_sec"`
+	P50Ns       int64   `json:"p50_ns"`
+	P99Ns       int64   `json:"p99_ns"`
+	P999Ns      int64   `json:"p999_ns"`
+	AllocsPerOp float64 `json:"allocs_per_op"`
+	BytesPerOp  float64 `json:"bytes_per_op"`
+}
+
+// run prefills a fresh structure and hammers it with cfg.threads workers for cfg.duration.
+func run(cfg *config) (result, error) {
+	s, err := lookupStructure(cfg.structure, cfg.threads)
+	if err != nil {
+		return result{}, err
+	}
+	t := s.build(cfg)
+
+	rng := rand.New(rand.NewSource(cfg.seed))
+	for i := 0; i < int(float64(cfg.keySpace)*cfg.prefill); i++ {
+		t.insert(rng.Intn(cfg.keySpace), i)
+	}
+
+	var (
+		stop  atomic.Bool
+		ready sync.WaitGroup
+		done  sync.WaitGroup
+		start = make(chan struct{})
+		hists = make([]histogram, cfg.threads)
+	)
+	ready.Add(cfg.threads)
+	done.Add(cfg.threads)
+	for w := 0; w < cfg.threads; w++ {
+		go func(h *histogram, seed int64) {
+			defer done.Done()
+			rng := rand.New(rand.NewSource(seed))
+			ready.Done()
+			<-start
+			for n := 0; ; n++ {
+				// checking the flag on every op would dominate the cheap operations
+				if n&63 == 0 && stop.Load() {
+					return
+				}
+				k := rng.Intn(cfg.keySpace)
+				p := rng.Intn(100)
+				begin := time.Now()
+				switch {
+				case p < cfg.mix.get:
+					t.get(k)
+				case p < cfg.mix.get+cfg.mix.insert:
+					t.insert(k, n)
+				default:
+					t.remove(k)
+				}
+				h.record(time.Since(begin))
+			}
+		}(&hists[w], cfg.seed+int64(w)+1)
+	}
+	ready.Wait()
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	begin := time.Now()
+	close(start)
+	time.Sleep(cfg.duration)
+	stop.Store(true)
+	done.Wait()
+	elapsed := time.Since(begin)
+	runtime.ReadMemStats(&after)
+
+	var total histogram
+	for i := range hists {
+		total.merge(&hists[i])
+	}
+	r := result{
+		Structure:  cfg.structure,
+		Threads:    cfg.threads,
+		KeySpace:   cfg.keySpace,
+		Mix:        cfg.mix.String(),
+		DurationNs: elapsed.Nanoseconds(),
+		Ops:        total.total,
+		P50Ns:      total.quantile(0.50).Nanoseconds(),
+		P99Ns:      total.quantile(0.99).Nanoseconds(),
+		P999Ns:     total.quantile(0.999).Nanoseconds(),
+	}
+	if total.total > 0 {
+		r.OpsPerSec = float64(total.total) / elapsed.Seconds()
+		r.AllocsPerOp = float64(after.Mallocs-before.Mallocs) / float64(total.total)
+		r.BytesPerOp = float64(after.TotalAlloc-before.TotalAlloc) / float64(total.total)
+	}
+	return r, nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMix(t *testing.T) {
+	m, err := parseMix("get=80, insert=15,remove=5")
+	assert.NoError(t, err)
+	assert.Equal(t, mix{get: 80, insert: 15, remove: 5}, m)
+
+	m, err = parseMix("insert=100")
+	assert.NoError(t, err)
+	assert.Equal(t, mix{insert: 100}, m)
+
+	_, err = parseMix("get=80,insert=10")
+	assert.Error(t, err)
+	_, err = parseMix("scan=100")
+	assert.Error(t, err)
+}
+
+func TestHistogram(t *testing.T) {
+	for _, ns := range []uint64{0, 1, 15, 16, 17, 100, 1000, 123456, 1 << 40} {
+		b := bucketOf(ns)
+		assert.LessOrEqual(t, lowerBound(b), ns)
+		assert.Greater(t, lowerBound(b+1), ns)
+	}
+
+	var h histogram
+	for i := 1; i <= 1000; i++ {
+		h.record(time.Duration(i) * time.Microsecond)
+	}
+	assert.InEpsilon(t, 500*time.Microsecond, h.quantile(0.5), 1.0/16)
+	assert.InEpsilon(t, 990*time.Microsecond, h.quantile(0.99), 1.0/16)
+	assert.InEpsilon(t, 1000*time.Microsecond, h.quantile(0.999), 1.0/16)
+}
+
+func TestRealMain(t *testing.T) {
+	var out bytes.Buffer
+	err := realMain([]string{
+		"-structure", "map,lockfree-list,lock-list",
+		"-threads", "1,2",
+		"-keys", "64",
+		"-duration", "20ms",
+		"-format", "json",
+	}, &out)
+	assert.NoError(t, err)
+
+	var results []result
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &results))
+	assert.Len(t, results, 6)
+	for _, r := range results {
+		assert.Positive(t, r.Ops)
+		assert.LessOrEqual(t, r.P50Ns, r.P99Ns)
+		assert.LessOrEqual(t, r.P99Ns, r.P999Ns)
+	}
+
+	out.Reset()
+	err = realMain([]string{"-structure", "unsafe-list", "-duration", "10ms", "-format", "csv"}, &out)
+	assert.NoError(t, err)
+	rows, err := csv.NewReader(&out).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, csvHeader, rows[0])
+
+	err = realMain([]string{"-structure", "unsafe-list", "-threads", "2"}, &out)
+	assert.Error(t, err)
+}
+
+func TestMemoryMode(t *testing.T) {
+	var out bytes.Buffer
+	err := realMain([]string{
+		"-mode", "memory",
+		"-structure", "map,lockfree-list,lock-list,unsafe-list",
+		"-keys", "2000",
+	}, &out)
+	assert.NoError(t, err)
+
+	var results []memResult
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &results))
+	assert.Len(t, results, 4)
+	for _, r := range results {
+		assert.Equal(t, 2000, r.Entries)
+		assert.Positive(t, r.EstimatedBytesPerEntry, r.Structure)
+		assert.GreaterOrEqual(t, r.AllocsPerInsert, 1.0, r.Structure)
+		// the estimate ignores allocator rounding, but should be in the right ballpark
+		assert.InEpsilon(t, r.BytesPerEntry, r.EstimatedBytesPerEntry, 0.5, r.Structure)
+	}
+
+	err = realMain([]string{"-mode", "profile"}, &out)
+	assert.Error(t, err)
+}
@@ -0,0 +1,84 @@
+package dump
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+)
+
+// Codec Encodes keys or values of type T to bytes and back.
+type Codec[T any] interface {
+	// Append Appends the encoding of v to dst and returns the extended buffer.
+	Append(dst []byte, v T) ([]byte, error)
+	// Decode Returns the value encoded in src, which it must not retain.
+	Decode(src []byte) (T, error)
+}
+
+// CodecFuncs A Codec made of two functions.
+type CodecFuncs[T any] struct {
+	AppendFunc func(dst []byte, v T) ([]byte, error)
+	DecodeFunc func(src []byte) (T, error)
+}
+
+func (c CodecFuncs[T]) Append(dst []byte, v T) ([]byte, error) { return c.AppendFunc(dst, v) }
+
+func (c CodecFuncs[T]) Decode(src []byte) (T, error) { return c.DecodeFunc(src) }
+
+// String Returns a codec storing strings as their bytes.
+func String[T ~string]() Codec[T] {
+	return CodecFuncs[T]{
+		AppendFunc: func(dst []byte, v T) ([]byte, error) { return append(dst, v...), nil },
+		DecodeFunc: func(src []byte) (T, error) { return T(src), nil },
+	}
+}
+
+// Bytes Returns a codec storing byte slices as they are, decoded slices are copies.
+func Bytes() Codec[[]byte] {
+	return CodecFuncs[[]byte]{
+		AppendFunc: func(dst []byte, v []byte) ([]byte, error) { return append(dst, v...), nil },
+		DecodeFunc: func(src []byte) ([]byte, error) { return append([]byte(nil), src...), nil },
+	}
+}
+
+// Int Returns a codec storing signed integers as zig-zag varints.
+func Int[T ~int | ~int8 | ~int16 | ~int32 | ~int64]() Codec[T] {
+	return CodecFuncs[T]{
+		AppendFunc: func(dst []byte, v T) ([]byte, error) { return binary.AppendVarint(dst, int64(v)), nil },
+		DecodeFunc: func(src []byte) (T, error) {
+			x, n := binary.Varint(src)
+			if n != len(src) || int64(T(x)) != x {
+				return 0, fmt.Errorf("%w: invalid %T", ErrCorrupt, T(0))
+			}
+			return T(x), nil
+		},
+	}
+}
+
+// Uint Returns a codec storing unsigned integers as varints.
+func Uint[T ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr]() Codec[T] {
+	return CodecFuncs[T]{
+		AppendFunc: func(dst []byte, v T) ([]byte, error) { return binary.AppendUvarint(dst, uint64(v)), nil },
+		DecodeFunc: func(src []byte) (T, error) {
+			x, n := binary.Uvarint(src)
+			if n != len(src) || uint64(T(x)) != x {
+				return 0, fmt.Errorf("%w: invalid %T", ErrCorrupt, T(0))
+			}
+			return T(x), nil
+		},
+	}
+}
+
+// JSON Returns a codec storing values as JSON, for types without a more compact codec.
+func JSON[T any]() Codec[T] {
+	return CodecFuncs[T]{
+		AppendFunc: func(dst []byte, v T) ([]byte, error) {
+			b, err := json.Marshal(v)
+			return append(dst, b...), err
+		},
+		DecodeFunc: func(src []byte) (T, error) {
+			var v T
+			err := json.Unmarshal(src, &v)
+			return v, err
+		},
+	}
+}
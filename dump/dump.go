@@ -0,0 +1,283 @@
+// Package dump Streams the entries of a map to an io.Writer and back, in a versioned binary
+// format.
+//
+// A dump is laid out as:
+//
+//	magic "RNDP" | version (1 byte) | entry* | end tag | count (uvarint) | checksum (4 bytes)
+//
+// where every entry is an entry tag followed by the key and the value, each as a uvarint
+// length and that many bytes encoded by a Codec. The checksum is the CRC-32 (Castagnoli)
+// of everything before it, little endian. The entries are written one by one as they are
+// produced, nothing is buffered beyond the current entry.
+package dump
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// Version The version of the format written by Writer.
+const Version = 1
+
+var magic = [4]byte{'R', 'N', 'D', 'P'}
+
+const (
+	tagEnd byte = iota
+	tagEntry
+)
+
+// maxFieldSize Bounds the length of a key or value, so that a corrupt length does not
+// allocate gigabytes before the checksum is checked.
+const maxFieldSize = 1 << 30
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+var (
+	// ErrFormat The input is not a dump.
+	ErrFormat = errors.New("dump: not a dump")
+	// ErrVersion The dump was written by an unsupported version of the format.
+	ErrVersion = errors.New("dump: unsupported version")
+	// ErrCorrupt The dump is truncated or damaged.
+	ErrCorrupt = errors.New("dump: corrupt dump")
+
+	errClosed = errors.New("dump: writer closed")
+)
+
+// Writer Writes entries to a dump.
+//
+// Close must be called after the last entry to write the trailer, a dump without it does
+// not load. After an error, the Writer keeps returning it.
+type Writer[K any, V any] struct {
+	w      io.Writer
+	keys   Codec[K]
+	values Codec[V]
+	crc    uint32
+	count  uint64
+	// the encoding of the current entry, and of its key or value
+	buf, field []byte
+	started    bool
+	err        error
+}
+
+// NewWriter Returns a Writer writing to w, with the codecs of the keys and values.
+func NewWriter[K any, V any](w io.Writer, keys Codec[K], values Codec[V]) *Writer[K, V] {
+	return &Writer[K, V]{w: w, keys: keys, values: values}
+}
+
+// Write Writes an entry.
+func (w *Writer[K, V]) Write(key K, value V) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.buf = append(w.header(), tagEntry)
+	if w.buf, w.err = appendField(w.buf, &w.field, w.keys, key); w.err != nil {
+		return w.err
+	}
+	if w.buf, w.err = appendField(w.buf, &w.field, w.values, value); w.err != nil {
+		return w.err
+	}
+	w.count++
+	return w.flush()
+}
+
+// Close Writes the trailer, it does not close the underlying io.Writer.
+func (w *Writer[K, V]) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	w.buf = append(w.header(), tagEnd)
+	w.buf = binary.AppendUvarint(w.buf, w.count)
+	if err := w.flush(); err != nil {
+		return err
+	}
+	w.buf = binary.LittleEndian.AppendUint32(w.buf[:0], w.crc)
+	if _, err := w.w.Write(w.buf); err != nil {
+		w.err = err
+		return err
+	}
+	w.err = errClosed
+	return nil
+}
+
+// header Returns the buffer emptied, or holding the header before the first write.
+func (w *Writer[K, V]) header() []byte {
+	if w.started {
+		return w.buf[:0]
+	}
+	w.started = true
+	return append(append(w.buf[:0], magic[:]...), Version)
+}
+
+func (w *Writer[K, V]) flush() error {
+	w.crc = crc32.Update(w.crc, crcTable, w.buf)
+	if _, err := w.w.Write(w.buf); err != nil {
+		w.err = err
+	}
+	return w.err
+}
+
+// appendField Appends v encoded by c with its length to dst, encoding it in scratch first.
+func appendField[T any](dst []byte, scratch *[]byte, c Codec[T], v T) ([]byte, error) {
+	var err error
+	if *scratch, err = c.Append((*scratch)[:0], v); err != nil {
+		return dst, err
+	}
+	dst = binary.AppendUvarint(dst, uint64(len(*scratch)))
+	return append(dst, *scratch...), nil
+}
+
+// Reader Reads the entries of a dump one by one, it implements skiplist.Iterator.
+//
+// The checksum is only verified once the last entry was read: Next returns `false` at the
+// end of the dump or on the first error, and Err tells which. Entries read from a dump that
+// turns out to be corrupt must be discarded.
+type Reader[K any, V any] struct {
+	in     checksummed
+	keys   Codec[K]
+	values Codec[V]
+	count  uint64
+	key    K
+	value  V
+	// whether the header was read, and the trailer
+	started, done bool
+	err           error
+}
+
+// NewReader Returns a Reader reading from r, with the codecs of the keys and values.
+//
+// It may read past the end of the dump.
+func NewReader[K any, V any](r io.Reader, keys Codec[K], values Codec[V]) *Reader[K, V] {
+	return &Reader[K, V]{in: checksummed{r: bufio.NewReader(r)}, keys: keys, values: values}
+}
+
+// Next Advances to the next entry and returns `false` at the end of the dump or on error.
+func (r *Reader[K, V]) Next() bool {
+	if r.done || r.err != nil {
+		return false
+	}
+	if r.err = r.next(); r.err != nil {
+		if errors.Is(r.err, io.EOF) || errors.Is(r.err, io.ErrUnexpectedEOF) {
+			r.err = fmt.Errorf("%w: %w", ErrCorrupt, io.ErrUnexpectedEOF)
+		}
+		return false
+	}
+	return !r.done
+}
+
+// Key Returns the key of the current entry.
+func (r *Reader[K, V]) Key() K {
+	return r.key
+}
+
+// Value Returns the value of the current entry.
+func (r *Reader[K, V]) Value() V {
+	return r.value
+}
+
+// Err Returns the error that stopped the Reader, nil once it read the whole dump.
+func (r *Reader[K, V]) Err() error {
+	return r.err
+}
+
+func (r *Reader[K, V]) next() error {
+	if !r.started {
+		r.started = true
+		header, err := r.in.read(len(magic) + 1)
+		if err != nil {
+			return ErrFormat
+		}
+		if [4]byte(header) != magic {
+			return ErrFormat
+		}
+		if header[len(magic)] != Version {
+			return fmt.Errorf("%w: %d", ErrVersion, header[len(magic)])
+		}
+	}
+
+	tag, err := r.in.ReadByte()
+	if err != nil {
+		return err
+	}
+	switch tag {
+	case tagEntry:
+		field, err := r.readField()
+		if err != nil {
+			return err
+		}
+		if r.key, err = r.keys.Decode(field); err != nil {
+			return err
+		}
+		if field, err = r.readField(); err != nil {
+			return err
+		}
+		if r.value, err = r.values.Decode(field); err != nil {
+			return err
+		}
+		r.count++
+		return nil
+	case tagEnd:
+		count, err := binary.ReadUvarint(&r.in)
+		if err != nil {
+			return err
+		}
+		if count != r.count {
+			return fmt.Errorf("%w: %d entries, expected %d", ErrCorrupt, r.count, count)
+		}
+		want := r.in.crc
+		sum, err := r.in.read(4)
+		if err != nil {
+			return err
+		}
+		if binary.LittleEndian.Uint32(sum) != want {
+			return fmt.Errorf("%w: checksum mismatch", ErrCorrupt)
+		}
+		r.done = true
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown tag %d", ErrCorrupt, tag)
+	}
+}
+
+func (r *Reader[K, V]) readField() ([]byte, error) {
+	n, err := binary.ReadUvarint(&r.in)
+	if err != nil {
+		return nil, err
+	}
+	if n > maxFieldSize {
+		return nil, fmt.Errorf("%w: field of %d bytes", ErrCorrupt, n)
+	}
+	return r.in.read(int(n))
+}
+
+// checksummed Reads from a buffered reader and keeps the checksum of what it read.
+type checksummed struct {
+	r   *bufio.Reader
+	crc uint32
+	buf []byte
+}
+
+// ReadByte Reads a byte, for binary.ReadUvarint.
+func (c *checksummed) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.crc = crc32.Update(c.crc, crcTable, []byte{b})
+	}
+	return b, err
+}
+
+// read Reads n bytes into a buffer that is reused by the next read.
+func (c *checksummed) read(n int) ([]byte, error) {
+	if cap(c.buf) < n {
+		c.buf = make([]byte, n)
+	}
+	c.buf = c.buf[:n]
+	if _, err := io.ReadFull(c.r, c.buf); err != nil {
+		return nil, err
+	}
+	c.crc = crc32.Update(c.crc, crcTable, c.buf)
+	return c.buf, nil
+}
@@ -0,0 +1,103 @@
+package dump
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type entry struct {
+	key   string
+	value int
+}
+
+func write(t *testing.T, entries ...entry) []byte {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, String[string](), Int[int]())
+	for _, e := range entries {
+		assert.NoError(t, w.Write(e.key, e.value))
+	}
+	assert.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func read(data []byte) ([]entry, error) {
+	r := NewReader(bytes.NewReader(data), String[string](), Int[int]())
+	var entries []entry
+	for r.Next() {
+		entries = append(entries, entry{r.Key(), r.Value()})
+	}
+	return entries, r.Err()
+}
+
+func TestRoundTrip(t *testing.T) {
+	want := []entry{{"a", 1}, {"", -7}, {"long key", 1 << 40}}
+	got, err := read(write(t, want...))
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	got, err = read(write(t))
+	assert.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestCorrupt(t *testing.T) {
+	data := write(t, entry{"a", 1}, entry{"b", 2})
+
+	_, err := read(nil)
+	assert.ErrorIs(t, err, ErrFormat)
+	_, err = read([]byte("not a dump"))
+	assert.ErrorIs(t, err, ErrFormat)
+
+	newer := bytes.Clone(data)
+	newer[len(magic)] = Version + 1
+	_, err = read(newer)
+	assert.ErrorIs(t, err, ErrVersion)
+
+	for n := len(magic) + 1; n < len(data); n++ {
+		_, err = read(data[:n])
+		assert.ErrorIs(t, err, ErrCorrupt, n)
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF, n)
+	}
+
+	// Flip a bit of the value of "b".
+	flipped := bytes.Clone(data)
+	flipped[bytes.IndexByte(flipped, 'b')+2] ^= 1
+	_, err = read(flipped)
+	assert.ErrorIs(t, err, ErrCorrupt)
+}
+
+func TestCodecs(t *testing.T) {
+	roundTrip := func(c Codec[any], v any) {
+		b, err := c.Append(nil, v)
+		assert.NoError(t, err)
+		got, err := c.Decode(b)
+		assert.NoError(t, err)
+		assert.Equal(t, v, got)
+	}
+	erase := func(c Codec[[]byte]) Codec[any] {
+		return CodecFuncs[any]{
+			AppendFunc: func(dst []byte, v any) ([]byte, error) { return c.Append(dst, v.([]byte)) },
+			DecodeFunc: func(src []byte) (any, error) { return c.Decode(src) },
+		}
+	}
+	roundTrip(erase(Bytes()), []byte{0, 1, 0xff})
+	roundTrip(JSON[any](), map[string]any{"a": []any{1.5, "x"}})
+
+	u := Uint[uint8]()
+	b, _ := Uint[uint64]().Append(nil, 300)
+	_, err := u.Decode(b)
+	assert.ErrorIs(t, err, ErrCorrupt)
+	v, err := Uint[uint16]().Decode(b)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 300, v)
+
+	b, _ = Int[int64]().Append(nil, -200)
+	_, err = Int[int8]().Decode(b)
+	assert.ErrorIs(t, err, ErrCorrupt)
+	i, err := Int[int16]().Decode(b)
+	assert.NoError(t, err)
+	assert.EqualValues(t, -200, i)
+}
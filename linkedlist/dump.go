@@ -0,0 +1,45 @@
+package linkedlist
+
+import (
+	"cmp"
+	"io"
+
+	"github.com/crrow/reona/dump"
+	"github.com/crrow/reona/util"
+)
+
+// Dump writes the entries of the map to w in the format of package dump and returns the
+// number of entries written. Entries are streamed while ranging over the map, so the dump
+// is as weakly consistent as Range: it is safe while the map is modified, but it is not a
+// point in time snapshot, and a key removed and inserted again meanwhile may be written
+// twice. Loading keeps the last value of such a key.
+func (m *Map[K, V]) Dump(w io.Writer, keys dump.Codec[K], values dump.Codec[V]) (uint64, error) {
+	dw := dump.NewWriter(w, keys, values)
+	var n uint64
+	var err error
+	m.Range(func(k K, v V) bool {
+		if err = dw.Write(k, v); err != nil {
+			return false
+		}
+		n++
+		return true
+	})
+	if err != nil {
+		return n, err
+	}
+	return n, dw.Close()
+}
+
+// LoadMap returns a map holding the entries of a dump read from r, the options are those of
+// NewMap. Nothing is returned unless the whole dump was read and its checksum matches.
+func LoadMap[K cmp.Ordered, V any](r io.Reader, keys dump.Codec[K], values dump.Codec[V], opts ...util.Option[Map[K, V]]) (*Map[K, V], error) {
+	m := NewMap[K, V](opts...)
+	dr := dump.NewReader(r, keys, values)
+	for dr.Next() {
+		m.Insert(dr.Key(), dr.Value())
+	}
+	if err := dr.Err(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,435 @@
+package linkedlist
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/crrow/reona/linkedlist/lock"
+	"github.com/crrow/reona/linkedlist/thread_unsafe"
+)
+
+// The fuzz targets below decode the input into a sequence of operations, run them against
+// a structure and check every result against a plain map or slice model.
+//
+// The concurrent variants spread the operations over a few goroutines, record what every
+// operation observed and then search for one sequential order that keeps the program order
+// of each goroutine and explains all observations and the final state.
+
+const (
+	fuzzKeySpace = 8
+	// maxConcurrentOps bounds the interleavings searched by sequentiallyConsistent.
+	maxConcurrentOps = 12
+	// maxUnsafeOps bounds FuzzThreadUnsafeLinkedList, whose model copies the whole list on
+	// some operations.
+	maxUnsafeOps = 256
+)
+
+// fuzzOp is one decoded operation: code selects the operation, g the goroutine running it.
+type fuzzOp struct {
+	code, g, key, val int
+}
+
+// decodeOps turns every 3 bytes of data into an operation.
+func decodeOps(data []byte, nCodes, nGoroutines int) []fuzzOp {
+	var ops []fuzzOp
+	for ; len(data) >= 3; data = data[3:] {
+		ops = append(ops, fuzzOp{
+			code: int(data[0]) % nCodes,
+			g:    int(data[0]>>4) % nGoroutines,
+			key:  int(data[1]) % fuzzKeySpace,
+			val:  int(data[2]),
+		})
+	}
+	return ops
+}
+
+// step applies an operation to a model state and reports whether the observed result
+// agrees with it. It must not modify s in place.
+type step[S any] func(s S) (S, bool)
+
+// sequentiallyConsistent reports whether histories, one per goroutine, could come from a
+// single sequential order whose final state is accepted by final.
+func sequentiallyConsistent[S any](init S, histories [][]step[S], final func(S) bool) bool {
+	pos := make([]int, len(histories))
+	var search func(s S) bool
+	search = func(s S) bool {
+		progressed := false
+		for g, h := range histories {
+			if pos[g] == len(h) {
+				continue
+			}
+			progressed = true
+			next, ok := h[pos[g]](s)
+			if !ok {
+				continue
+			}
+			pos[g]++
+			found := search(next)
+			pos[g]--
+			if found {
+				return true
+			}
+		}
+		return !progressed && final(s)
+	}
+	return search(init)
+}
+
+// runConcurrently runs every goroutine's operations at the same time.
+func runConcurrently(nGoroutines int, ops []fuzzOp, run func(g int, op fuzzOp)) {
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	wg.Add(nGoroutines)
+	for g := 0; g < nGoroutines; g++ {
+		go func(g int) {
+			defer wg.Done()
+			<-start
+			for _, op := range ops {
+				if op.g == g {
+					run(g, op)
+				}
+			}
+		}(g)
+	}
+	close(start)
+	wg.Wait()
+}
+
+func seedCorpus(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{0, 1, 1, 1, 1, 0, 2, 1, 0})
+	f.Add([]byte{0, 1, 1, 0, 2, 2, 2, 1, 0, 1, 1, 0, 0, 1, 3})
+	f.Add([]byte{0x10, 3, 7, 0x01, 3, 9, 0x12, 3, 0, 0x02, 5, 0, 0x00, 5, 5})
+	f.Add([]byte{0x20, 0, 1, 0x10, 1, 2, 0x00, 2, 3, 0x22, 0, 0, 0x12, 1, 0, 0x02, 2, 0})
+}
+
+// mapModel is copied on write, so it can be shared between branches of the search.
+type mapModel map[int]int
+
+func (m mapModel) with(k, v int) mapModel {
+	c := maps.Clone(m)
+	if c == nil {
+		c = mapModel{}
+	}
+	c[k] = v
+	return c
+}
+
+func (m mapModel) without(k int) mapModel {
+	c := maps.Clone(m)
+	delete(c, k)
+	return c
+}
+
+// keyed is what Map and LinkedList have in common.
+type keyed interface {
+	insert(k, v int)
+	get(k int) (int, bool)
+	remove(k int) bool
+	len() (int, bool)
+}
+
+type keyedMap struct{ m *Map[int, int] }
+
+func (t keyedMap) insert(k, v int) { t.m.Insert(k, v) }
+func (t keyedMap) get(k int) (int, bool) {
+	v, ok := t.m.Get(k)
+	if !ok {
+		return 0, false
+	}
+	return *v, true
+}
+func (t keyedMap) remove(k int) bool { return t.m.Remove(k) }
+func (t keyedMap) len() (int, bool)  { return int(t.m.Len()), true }
+func newKeyedMap(nBucket uint64) keyed {
+	return keyedMap{NewMap[int, int](WithCapacity[int, int](nBucket))}
+}
+
+type keyedList struct{ l *LinkedList[int, int] }
+
+func (t keyedList) insert(k, v int) { t.l.Insert(k, v) }
+func (t keyedList) get(k int) (int, bool) {
+	v := t.l.Get(k)
+	if v == nil {
+		return 0, false
+	}
+	return *v.Load(), true
+}
+func (t keyedList) remove(k int) bool { return t.l.Remove(k) }
+func (t keyedList) len() (int, bool)  { return 0, false }
+
+const (
+	opInsert = iota
+	opGet
+	opRemove
+	nKeyedOps
+)
+
+// applyKeyed runs op against t and returns the model step that validates its result.
+func applyKeyed(t keyed, op fuzzOp) step[mapModel] {
+	switch op.code {
+	case opInsert:
+		t.insert(op.key, op.val)
+		return func(m mapModel) (mapModel, bool) { return m.with(op.key, op.val), true }
+	case opGet:
+		v, ok := t.get(op.key)
+		return func(m mapModel) (mapModel, bool) {
+			want, wantOk := m[op.key]
+			return m, ok == wantOk && v == want
+		}
+	default:
+		ok := t.remove(op.key)
+		return func(m mapModel) (mapModel, bool) {
+			_, wantOk := m[op.key]
+			return m.without(op.key), ok == wantOk
+		}
+	}
+}
+
+// finalKeyed checks that t holds exactly the model's entries.
+func finalKeyed(t keyed) func(mapModel) bool {
+	var got mapModel
+	for k := 0; k < fuzzKeySpace; k++ {
+		if v, ok := t.get(k); ok {
+			got = got.with(k, v)
+		}
+	}
+	n, hasLen := t.len()
+	return func(m mapModel) bool {
+		return maps.Equal(got, m) && (!hasLen || n == len(m))
+	}
+}
+
+func fuzzKeyed(f *testing.F, build func() keyed) {
+	seedCorpus(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		s := build()
+		var history []step[mapModel]
+		for _, op := range decodeOps(data, nKeyedOps, 1) {
+			history = append(history, applyKeyed(s, op))
+		}
+		if !sequentiallyConsistent(nil, [][]step[mapModel]{history}, finalKeyed(s)) {
+			t.Fatalf("results of %v differ from the model", decodeOps(data, nKeyedOps, 1))
+		}
+	})
+}
+
+func fuzzKeyedConcurrent(f *testing.F, build func() keyed) {
+	seedCorpus(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if len(data) == 0 {
+			return
+		}
+		nGoroutines := 2 + int(data[0])%2
+		ops := decodeOps(data[1:], nKeyedOps, nGoroutines)
+		if len(ops) > maxConcurrentOps {
+			ops = ops[:maxConcurrentOps]
+		}
+		s := build()
+		histories := make([][]step[mapModel], nGoroutines)
+		runConcurrently(nGoroutines, ops, func(g int, op fuzzOp) {
+			histories[g] = append(histories[g], applyKeyed(s, op))
+		})
+		if !sequentiallyConsistent(nil, histories, finalKeyed(s)) {
+			t.Fatalf("no sequential order explains %v", ops)
+		}
+	})
+}
+
+func FuzzMap(f *testing.F) {
+	fuzzKeyed(f, func() keyed { return newKeyedMap(3) })
+}
+
+func FuzzMapConcurrent(f *testing.F) {
+	fuzzKeyedConcurrent(f, func() keyed { return newKeyedMap(3) })
+}
+
+func FuzzLinkedList(f *testing.F) {
+	fuzzKeyed(f, func() keyed { return keyedList{New[int, int]()} })
+}
+
+func FuzzLinkedListConcurrent(f *testing.F) {
+	fuzzKeyedConcurrent(f, func() keyed { return keyedList{New[int, int]()} })
+}
+
+// listModel is a copy on write deque.
+type listModel []int
+
+func (l listModel) pushFront(v int) listModel { return append(listModel{v}, l...) }
+func (l listModel) pushBack(v int) listModel  { return append(slices.Clone(l), v) }
+
+const (
+	opPush = iota
+	opPushFront
+	opPop
+	opPeek
+	opPeekTail
+	opIsEmpty
+	nLockOps
+)
+
+func applyLock(l *lock.LinkedList[int], op fuzzOp) step[listModel] {
+	switch op.code {
+	case opPush:
+		l.Push(op.val)
+		return func(m listModel) (listModel, bool) { return m.pushBack(op.val), true }
+	case opPushFront:
+		l.PushFront(op.val)
+		return func(m listModel) (listModel, bool) { return m.pushFront(op.val), true }
+	case opPop:
+		v, ok := l.Pop()
+		return func(m listModel) (listModel, bool) {
+			if len(m) == 0 {
+				return m, !ok
+			}
+			return m[1:], ok && v == m[0]
+		}
+	case opPeek:
+		v, ok := l.Peek()
+		return func(m listModel) (listModel, bool) {
+			return m, ok == (len(m) > 0) && (!ok || v == m[0])
+		}
+	case opPeekTail:
+		v, ok := l.PeekTail()
+		return func(m listModel) (listModel, bool) {
+			return m, ok == (len(m) > 0) && (!ok || v == m[len(m)-1])
+		}
+	default:
+		empty := l.IsEmpty()
+		return func(m listModel) (listModel, bool) { return m, empty == (len(m) == 0) }
+	}
+}
+
+// finalLock drains l, so it must only be called once all operations are done.
+func finalLock(l *lock.LinkedList[int]) func(listModel) bool {
+	var got listModel
+	for v, ok := l.Pop(); ok; v, ok = l.Pop() {
+		got = append(got, v)
+	}
+	return func(m listModel) bool { return slices.Equal(got, m) }
+}
+
+func FuzzLockLinkedList(f *testing.F) {
+	seedCorpus(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		l := lock.NewLinkedList[int]()
+		var history []step[listModel]
+		for _, op := range decodeOps(data, nLockOps, 1) {
+			history = append(history, applyLock(l, op))
+		}
+		if !sequentiallyConsistent(nil, [][]step[listModel]{history}, finalLock(l)) {
+			t.Fatalf("results of %v differ from the model", decodeOps(data, nLockOps, 1))
+		}
+	})
+}
+
+func FuzzLockLinkedListConcurrent(f *testing.F) {
+	seedCorpus(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if len(data) == 0 {
+			return
+		}
+		nGoroutines := 2 + int(data[0])%2
+		ops := decodeOps(data[1:], nLockOps, nGoroutines)
+		if len(ops) > maxConcurrentOps {
+			ops = ops[:maxConcurrentOps]
+		}
+		l := lock.NewLinkedList[int]()
+		histories := make([][]step[listModel], nGoroutines)
+		runConcurrently(nGoroutines, ops, func(g int, op fuzzOp) {
+			histories[g] = append(histories[g], applyLock(l, op))
+		})
+		if !sequentiallyConsistent(nil, histories, finalLock(l)) {
+			t.Fatalf("no sequential order explains %v", ops)
+		}
+	})
+}
+
+const (
+	opUnsafePushFront = iota
+	opUnsafePushBack
+	opUnsafePopFront
+	opUnsafePopBack
+	opUnsafeInsertAfterFront
+	opUnsafeInsertBeforeBack
+	opUnsafeMoveFrontToBack
+	opUnsafeRemoveBack
+	nUnsafeOps
+)
+
+// FuzzThreadUnsafeLinkedList has no concurrent variant, the list is not meant to be shared.
+func FuzzThreadUnsafeLinkedList(f *testing.F) {
+	seedCorpus(f)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ops := decodeOps(data, nUnsafeOps, 1)
+		if len(ops) > maxUnsafeOps {
+			ops = ops[:maxUnsafeOps]
+		}
+		l := thread_unsafe.New[int]()
+		var m listModel
+		for _, op := range ops {
+			switch op.code {
+			case opUnsafePushFront:
+				l.PushFront(op.val)
+				m = m.pushFront(op.val)
+			case opUnsafePushBack:
+				l.PushBack(op.val)
+				m = m.pushBack(op.val)
+			case opUnsafePopFront:
+				var want int
+				if len(m) > 0 {
+					want, m = m[0], m[1:]
+				}
+				if got := l.PopFront(); got != want {
+					t.Fatalf("PopFront = %d, want %d", got, want)
+				}
+			case opUnsafePopBack:
+				var want int
+				if len(m) > 0 {
+					want, m = m[len(m)-1], m[:len(m)-1]
+				}
+				if got := l.PopBack(); got != want {
+					t.Fatalf("PopBack = %d, want %d", got, want)
+				}
+			case opUnsafeInsertAfterFront:
+				if l.InsertAfter(op.val, l.Front()) != nil {
+					m = slices.Insert(slices.Clone(m), 1, op.val)
+				}
+			case opUnsafeInsertBeforeBack:
+				if l.InsertBefore(op.val, l.Back()) != nil {
+					m = slices.Insert(slices.Clone(m), len(m)-1, op.val)
+				}
+			case opUnsafeMoveFrontToBack:
+				if len(m) > 0 {
+					l.MoveToBack(l.Front())
+					m = append(slices.Clone(m[1:]), m[0])
+				}
+			default:
+				if len(m) > 0 {
+					l.Remove(l.Back())
+					m = m[:len(m)-1]
+				}
+			}
+
+			if l.Len() != len(m) {
+				t.Fatalf("Len = %d, want %d", l.Len(), len(m))
+			}
+		}
+
+		// Broken links stay broken, walking the list once at the end finds them.
+		var forward, backward listModel
+		l.Range(func(e *thread_unsafe.Element[int]) bool {
+			forward = append(forward, e.Value)
+			return true
+		})
+		for e := l.Back(); e != nil; e = e.Prev() {
+			backward = append(backward, e.Value)
+		}
+		slices.Reverse(backward)
+		if !slices.Equal(forward, m) || !slices.Equal(backward, m) {
+			t.Fatalf("list is %v forward and %v backward, want %v", forward, backward, m)
+		}
+	})
+}
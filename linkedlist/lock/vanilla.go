@@ -1,6 +1,9 @@
 package lock
 
-import "sync"
+import (
+	"sync"
+	"unsafe"
+)
 
 // LinkedList implements a pointer-linked list with a head and tail.
 //
@@ -111,6 +114,18 @@ func (l *LinkedList[T]) Reset() {
 	l.mtx.Unlock()
 }
 
+// MemoryUsage estimates the bytes held by the linked list: the list itself plus one
+// element per value. Pointers inside values are not followed.
+func (l *LinkedList[T]) MemoryUsage() uint64 {
+	l.mtx.RLock()
+	r := uint64(unsafe.Sizeof(*l))
+	for elem := l.head; elem != nil; elem = elem.next {
+		r += uint64(unsafe.Sizeof(*elem))
+	}
+	l.mtx.RUnlock()
+	return r
+}
+
 // pushElem pushes an element to the list while mtx is locked.
 func (l *LinkedList[T]) pushElem(val T) {
 	elem := &linkedListElem[T]{val: val}
@@ -3,6 +3,7 @@ package linkedlist
 import (
 	"cmp"
 	"sync/atomic"
+	"unsafe"
 )
 
 type Node[K cmp.Ordered, V any] struct {
@@ -31,15 +32,24 @@ func New[K cmp.Ordered, V any]() *LinkedList[K, V] {
 }
 
 func (l *LinkedList[K, V]) Insert(k K, v V) {
+	l.insert(k, v)
+}
+
+// insert reports whether a new node was linked, false means an existing value was replaced.
+func (l *LinkedList[K, V]) insert(k K, v V) bool {
 	var curAtomicPtrToNode = &l.head // &AtomicPtr
+	var prevNode *Node[K, V]         // the node curAtomicPtrToNode belongs to, nil for the head
 	for {
 		var curNode = curAtomicPtrToNode.Load() // *Node
-		if curNode == nil {                     // the current node is null, store point here
+		if curNode == nil {                     // the end of the list, append here
 			newNodePtr := newNode(k, v)
-			if !l.head.CompareAndSwap(curNode, newNodePtr) {
-				continue // TODO: will we actually load it again ?
+			newNodePtr.prev.Store(prevNode)
+			// CAS the slot we walked to, not the head: if the node that beat us to it has
+			// been removed since, the slot is nil again and we retry on it.
+			if !curAtomicPtrToNode.CompareAndSwap(nil, newNodePtr) {
+				continue // someone else appended first, keep walking from the slot
 			}
-			return
+			return true
 		}
 		// find the same key
 		if curNode.key == k && curNode.active.Load() {
@@ -50,17 +60,10 @@ func (l *LinkedList[K, V]) Insert(k K, v V) {
 			}
 			// cas succeed
 			_ = originalNodeValPtr
-			return
-		}
-		curAtomicPtrToNode = &curNode.next // point to next
-		// key does not exist
-		nextNodePtr := curNode.next.Load()
-		if nextNodePtr == nil {
-			insNodePtr := newNode(k, v)
-			insNodePtr.prev.Store(curNode)               // we won't fail here
-			curNode.next.CompareAndSwap(nil, insNodePtr) // we may fail here
-			return
+			return false
 		}
+		// key does not exist yet, point to next
+		prevNode, curAtomicPtrToNode = curNode, &curNode.next
 	}
 }
 
@@ -99,7 +102,7 @@ func (l *LinkedList[K, V]) Remove(k K) bool {
 						// someone may delete it already
 						return false
 					}
-					if !next.prev.CompareAndSwap(curNode, next) {
+					if !next.prev.CompareAndSwap(curNode, prev) {
 						return false
 					}
 				} else { // prev == nil
@@ -128,3 +131,30 @@ func (l *LinkedList[K, V]) Remove(k K) bool {
 		curAtomicPtrToNode = &curNode.next
 	}
 }
+
+// Range calls f for every active entry from the head of the list until f returns false.
+// Entries inserted or removed while ranging may or may not be visited.
+func (l *LinkedList[K, V]) Range(f func(k K, v V) bool) {
+	for n := l.head.Load(); n != nil; n = n.next.Load() {
+		if !n.active.Load() {
+			continue
+		}
+		if !f(n.key, *n.val.Load()) {
+			return
+		}
+	}
+}
+
+// MemoryUsage estimates the bytes held by the list: the list itself plus a node and a boxed
+// value per entry, including removed nodes that are still linked. It does not follow
+// pointers inside keys and values, so the backing arrays of strings and slices are not
+// counted, neither are values that were replaced but are still referenced elsewhere.
+func (l *LinkedList[K, V]) MemoryUsage() uint64 {
+	var v V
+	perNode := uint64(unsafe.Sizeof(Node[K, V]{}) + unsafe.Sizeof(v))
+	r := uint64(unsafe.Sizeof(*l))
+	for n := l.head.Load(); n != nil; n = n.next.Load() {
+		r += perNode
+	}
+	return r
+}
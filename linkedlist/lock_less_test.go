@@ -1,32 +1,172 @@
 package linkedlist
 
 import (
+	"fmt"
+	"math/rand"
+	"runtime"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/crrow/reona/skiplist"
+	"github.com/crrow/reona/skiplist/lazy"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestLockFreeLinkedList(t *testing.T) {
 	l := New[int, int]()
 	var wg sync.WaitGroup
+	wg.Add(3)
 	go func() {
-		wg.Add(1)
+		defer wg.Done()
 		var r *atomic.Pointer[int]
 		for r == nil {
 			r = l.Get(1)
 		}
 	}()
 	go func() {
-		wg.Add(1)
+		defer wg.Done()
 		r := l.Get(2)
 		assert.Nil(t, r)
 	}()
 	go func() {
-		wg.Add(1)
+		defer wg.Done()
 		l.Insert(1, 1)
 	}()
 
 	wg.Wait()
 }
+
+func TestInsertReportsNewNode(t *testing.T) {
+	l := New[int, int]()
+	assert.True(t, l.insert(1, 1))
+	assert.False(t, l.insert(1, 2))
+	assert.Equal(t, 2, *l.Get(1).Load())
+	assert.True(t, l.Remove(1))
+	assert.True(t, l.insert(1, 3))
+}
+
+func TestConcurrentAppend(t *testing.T) {
+	// Appends racing for the same tail must all be linked: the loser of the CAS walks on
+	// from the new tail instead of dropping its node. The race needs several CPUs to show.
+	const rounds, goroutines = 500, 8
+	for r := 0; r < rounds; r++ {
+		l := New[int, int]()
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		for g := 0; g < goroutines; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				<-start
+				l.Insert(g, g)
+			}(g)
+		}
+		close(start)
+		wg.Wait()
+		for g := 0; g < goroutines; g++ {
+			if !assert.NotNil(t, l.Get(g), "round %d key %d", r, g) {
+				return
+			}
+		}
+	}
+}
+
+func TestAppendRacesTailRemoval(t *testing.T) {
+	// Two appends race for the tail and each removes its node right after, so the loser of
+	// the CAS can find the slot empty again. It must retry on that slot, not on the head,
+	// or it spins until something else is appended. The race needs several CPUs to show.
+	const rounds = 2000
+	for r := 0; r < rounds; r++ {
+		l := New[int, int]()
+		l.Insert(0, 0)
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		for g := 1; g <= 2; g++ {
+			wg.Add(1)
+			go func(g int) {
+				defer wg.Done()
+				<-start
+				l.Insert(g, g)
+				runtime.Gosched() // let the loser fail its CAS before the node goes
+				l.Remove(g)
+			}(g)
+		}
+		close(start)
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("round %d: an append never returned", r)
+		}
+		assert.NotNil(t, l.Get(0), "round %d", r)
+	}
+}
+
+func TestRemoveRelinksPrev(t *testing.T) {
+	l := New[int, int]()
+	for i := 1; i <= 4; i++ {
+		l.Insert(i, i)
+	}
+	assert.True(t, l.Remove(2))
+	// 3 now points back to 1, not to itself
+	three := l.head.Load().next.Load()
+	assert.Equal(t, 3, three.key)
+	assert.Equal(t, 1, three.prev.Load().key)
+
+	assert.True(t, l.Remove(3))
+	assert.Nil(t, l.Get(3))
+	assert.NotNil(t, l.Get(1))
+	assert.NotNil(t, l.Get(4))
+}
+
+// BenchmarkSkipLists Runs the same workloads against the lock-free skip list and the lazy
+// skip list: a mix of gets, inserts and removes over a key range that starts half full.
+func BenchmarkSkipLists(b *testing.B) {
+	const keys = 1 << 16
+	for _, mix := range []struct {
+		name string
+		// percentages of gets and inserts, the rest are removes
+		get, insert int
+	}{
+		{"read-heavy", 90, 9},
+		{"balanced", 50, 25},
+		{"write-heavy", 10, 45},
+	} {
+		for _, m := range []struct {
+			name string
+			new  func() skiplist.Map[int, int]
+		}{
+			{"lockfree", func() skiplist.Map[int, int] { return skiplist.New[int, int]() }},
+			{"lazy", func() skiplist.Map[int, int] { return lazy.New[int, int]() }},
+		} {
+			b.Run(fmt.Sprintf("%s/%s", mix.name, m.name), func(b *testing.B) {
+				sl := m.new()
+				for k := 0; k < keys; k += 2 {
+					sl.Insert(k, k)
+				}
+				b.ReportAllocs()
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					rng := rand.New(rand.NewSource(rand.Int63()))
+					for pb.Next() {
+						k := rng.Intn(keys)
+						switch op := rng.Intn(100); {
+						case op < mix.get:
+							sl.Get(k)
+						case op < mix.get+mix.insert:
+							sl.Insert(k, k)
+						default:
+							sl.Remove(k)
+						}
+					}
+				})
+			})
+		}
+	}
+}
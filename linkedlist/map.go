@@ -2,9 +2,9 @@ package linkedlist
 
 import (
 	"cmp"
-	"fmt"
 	"github.com/crrow/reona/util"
 	"sync/atomic"
+	"unsafe"
 )
 
 type Map[K cmp.Ordered, V any] struct {
@@ -40,15 +40,14 @@ func (m *Map[K, V]) IsEmpty() bool {
 
 func (m *Map[K, V]) Insert(k K, v V) {
 	ndx := uint64(m.hasher(k)) % m.bSize
-	m.mp[ndx].Insert(k, v)
-	m.size.Add(1)
-	fmt.Printf("insert %v, index: %d \n", k, ndx)
+	if m.mp[ndx].insert(k, v) {
+		m.size.Add(1)
+	}
 }
 
 func (m *Map[K, V]) Get(k K) (*V, bool) {
 	ndx := uint64(m.hasher(k)) % m.bSize
 	r := m.mp[ndx].Get(k)
-	fmt.Printf("try get %v, index: %d \n", k, ndx)
 	if r == nil {
 		return nil, false
 	}
@@ -57,7 +56,6 @@ func (m *Map[K, V]) Get(k K) (*V, bool) {
 
 func (m *Map[K, V]) Remove(k K) bool {
 	ndx := uint64(m.hasher(k)) % m.bSize
-	fmt.Printf("try remove %v, index: %d \n", k, ndx)
 	if m.mp[ndx].Remove(k) {
 		var cur = m.size.Load()
 		for !m.size.CompareAndSwap(cur, cur-1) {
@@ -67,3 +65,28 @@ func (m *Map[K, V]) Remove(k K) bool {
 	}
 	return false
 }
+
+// Range calls f for every entry, bucket by bucket, until f returns false. Like
+// LinkedList.Range it does not block writers and is weakly consistent: entries present for
+// the whole call are visited, others may or may not be.
+func (m *Map[K, V]) Range(f func(k K, v V) bool) {
+	for _, l := range m.mp {
+		cont := true
+		l.Range(func(k K, v V) bool {
+			cont = f(k, v)
+			return cont
+		})
+		if !cont {
+			return
+		}
+	}
+}
+
+// MemoryUsage estimates the bytes held by the map, see LinkedList.MemoryUsage.
+func (m *Map[K, V]) MemoryUsage() uint64 {
+	r := uint64(unsafe.Sizeof(*m)) + uint64(cap(m.mp))*uint64(unsafe.Sizeof(m.mp[0]))
+	for _, l := range m.mp {
+		r += l.MemoryUsage()
+	}
+	return r
+}
@@ -1,6 +1,7 @@
 package linkedlist
 
 import (
+	"bytes"
 	"fmt"
 	"hash/maphash"
 	"sync"
@@ -8,6 +9,7 @@ import (
 	"time"
 	"unsafe"
 
+	"github.com/crrow/reona/dump"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -62,6 +64,18 @@ func TestMap(t *testing.T) {
 	wg.Wait()
 }
 
+func TestMapLenCountsKeysOnce(t *testing.T) {
+	mem := NewMap[string, int](WithCapacity[string, int](4))
+	mem.Insert("a", 1)
+	mem.Insert("a", 2)
+	assert.EqualValues(t, 1, mem.Len())
+	r, ok := mem.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 2, *r)
+	assert.True(t, mem.Remove("a"))
+	assert.True(t, mem.IsEmpty())
+}
+
 func TestDeleteWhileRead(t *testing.T) {
 	mem := NewMap[string, int](WithCapacity[string, int](10))
 	mem.Insert("hello", 1)
@@ -98,3 +112,69 @@ func calculateHash(seed maphash.Seed, v any) uint64 {
 	s := unsafe.Slice((*byte)(unsafe.Pointer(&v)), unsafe.Sizeof(v))
 	return maphash.Bytes(seed, s)
 }
+
+func TestMapMemoryUsage(t *testing.T) {
+	mem := NewMap[int, int](WithCapacity[int, int](4))
+	empty := mem.MemoryUsage()
+	for i := 0; i < 100; i++ {
+		mem.Insert(i, i)
+	}
+	full := mem.MemoryUsage()
+	perEntry := uint64(unsafe.Sizeof(Node[int, int]{}) + unsafe.Sizeof(0))
+	assert.Equal(t, empty+100*perEntry, full)
+
+	// replacing a value does not add a node
+	mem.Insert(1, 2)
+	assert.Equal(t, full, mem.MemoryUsage())
+
+	for i := 0; i < 100; i++ {
+		assert.True(t, mem.Remove(i))
+	}
+	assert.Equal(t, empty, mem.MemoryUsage())
+}
+
+func TestMapRange(t *testing.T) {
+	mem := NewMap[int, int](WithCapacity[int, int](4))
+	for i := 0; i < 10; i++ {
+		mem.Insert(i, i*i)
+	}
+	mem.Remove(3)
+	seen := map[int]int{}
+	mem.Range(func(k, v int) bool {
+		seen[k] = v
+		return true
+	})
+	assert.Len(t, seen, 9)
+	assert.Equal(t, 81, seen[9])
+	assert.NotContains(t, seen, 3)
+
+	n := 0
+	mem.Range(func(k, v int) bool {
+		n++
+		return n < 4
+	})
+	assert.Equal(t, 4, n)
+}
+
+func TestMapDumpLoad(t *testing.T) {
+	mem := NewMap[string, int](WithCapacity[string, int](4))
+	for i := 0; i < 100; i++ {
+		mem.Insert(fmt.Sprint("key", i), i)
+	}
+	var buf bytes.Buffer
+	n, err := mem.Dump(&buf, dump.String[string](), dump.Int[int]())
+	assert.NoError(t, err)
+	assert.EqualValues(t, 100, n)
+
+	loaded, err := LoadMap(bytes.NewReader(buf.Bytes()), dump.String[string](), dump.Int[int](), WithCapacity[string, int](8))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 100, loaded.Len())
+	r, ok := loaded.Get("key42")
+	assert.True(t, ok)
+	assert.Equal(t, 42, *r)
+
+	data := bytes.Clone(buf.Bytes())
+	data[len(data)-1] ^= 1
+	_, err = LoadMap(bytes.NewReader(data), dump.String[string](), dump.Int[int](), WithCapacity[string, int](8))
+	assert.ErrorIs(t, err, dump.ErrCorrupt)
+}
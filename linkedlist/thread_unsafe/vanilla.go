@@ -24,6 +24,8 @@ SOFTWARE.
 */
 package thread_unsafe
 
+import "unsafe"
+
 type (
 	Element[T any] struct {
 		prev, next *Element[T]
@@ -60,6 +62,11 @@ func (c *LinkedList[T]) Len() int {
 	return c.length
 }
 
+// MemoryUsage 估算链表占用的字节数, 不包括元素值内部指针指向的内存
+func (c *LinkedList[T]) MemoryUsage() uint64 {
+	return uint64(unsafe.Sizeof(*c)) + uint64(c.length)*uint64(unsafe.Sizeof(Element[T]{}))
+}
+
 // Front 获取头部元素
 func (c *LinkedList[T]) Front() *Element[T] {
 	return c.head
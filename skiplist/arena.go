@@ -0,0 +1,64 @@
+package skiplist
+
+import (
+	"errors"
+	"sync/atomic"
+	"unsafe"
+)
+
+// ErrArenaFull The arena has no room left for a new node.
+var ErrArenaFull = errors.New("skiplist: arena is full")
+
+// arena A fixed size buffer that nodes are carved out of.
+//
+// Memory is never freed one node at a time, the whole arena goes away with the memtable.
+// Nodes reference each other by offset, so the garbage collector sees one big allocation
+// without any pointers in it instead of millions of small ones.
+type arena struct {
+	buf []byte
+	// the offset of the first free byte
+	n atomic.Uint32
+}
+
+// nullOffset The offset of no node, nothing is ever allocated at 0.
+const nullOffset = 0
+
+func newArena(capacity uint32) *arena {
+	// back the buffer by uint64s so that offsets aligned in the buffer are aligned in memory
+	words := make([]uint64, (uint64(capacity)+7)/8)
+	a := &arena{buf: unsafe.Slice((*byte)(unsafe.Pointer(unsafe.SliceData(words))), capacity)}
+	// reserve offset 0 for nullOffset
+	a.n.Store(1)
+	return a
+}
+
+// size Returns the number of bytes allocated so far.
+func (a *arena) size() uint32 {
+	return min(a.n.Load(), uint32(len(a.buf)))
+}
+
+// alloc Returns the offset of size fresh bytes aligned to align, a power of two.
+func (a *arena) alloc(size, align uint32) (uint32, error) {
+	// Reserve enough room to align the offset whatever the current end is.
+	padded := uint64(size) + uint64(align) - 1
+	if padded > uint64(len(a.buf)) {
+		return 0, ErrArenaFull
+	}
+	end := a.n.Add(uint32(padded))
+	if uint64(end) > uint64(len(a.buf)) || end < uint32(padded) {
+		// Leave n past the end, every later allocation fails too.
+		a.n.Store(uint32(len(a.buf)))
+		return 0, ErrArenaFull
+	}
+	return (end - uint32(padded) + align - 1) &^ (align - 1), nil
+}
+
+// bytes Returns the size bytes at offset.
+func (a *arena) bytes(offset, size uint32) []byte {
+	return a.buf[offset : offset+size : offset+size]
+}
+
+// uint32At Returns the 4 aligned bytes at offset as an atomic.
+func (a *arena) uint32At(offset uint32) *atomic.Uint32 {
+	return (*atomic.Uint32)(unsafe.Pointer(&a.buf[offset]))
+}
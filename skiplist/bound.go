@@ -1,75 +1,56 @@
-//go:build exclude
-
 package skiplist
 
-// TODO: GET RID OF IT, very disgusting
-
-type bounder[K comparable] interface {
-	flag() boundFlag
-	key() K
-}
+import "fmt"
 
-type boundFlag uint8
+// boundKind Tells how a Bound limits a range of keys.
+type boundKind uint8
 
 const (
-	included boundFlag = iota
+	unbounded boundKind = iota
+	included
 	excluded
-	unbounded
 )
 
-type baseBound[K comparable] struct {
-	_key K
-}
-
-func (t baseBound[K]) key() K {
-	return t._key
+// Bound One end of a range of keys: included, excluded or unbounded.
+//
+// The zero value is unbounded.
+type Bound[K any] struct {
+	key  K
+	kind boundKind
 }
 
-type includedBound[K comparable] struct {
-	baseBound[K]
+// Included Returns a bound that includes key.
+func Included[K any](key K) Bound[K] {
+	return Bound[K]{key: key, kind: included}
 }
 
-func (ib includedBound[K]) flag() boundFlag {
-	return included
+// Excluded Returns a bound that excludes key.
+func Excluded[K any](key K) Bound[K] {
+	return Bound[K]{key: key, kind: excluded}
 }
 
-type excludedBound[K comparable] struct {
-	baseBound[K]
+// Unbounded Returns a bound that does not limit the range.
+func Unbounded[K any]() Bound[K] {
+	return Bound[K]{}
 }
 
-func (ib excludedBound[K]) flag() boundFlag {
-	return excluded
+// Key Returns the key of the bound, `false` if it is unbounded.
+func (b Bound[K]) Key() (K, bool) {
+	return b.key, b.kind != unbounded
 }
 
-type unboundedBound[K comparable] struct{}
-
-func (ib unboundedBound[K]) flag() boundFlag {
-	return unbounded
-}
-
-func (ib unboundedBound[K]) key() K {
-	panic("should not call me")
+// IsIncluded Returns whether the key of the bound is part of the range.
+func (b Bound[K]) IsIncluded() bool {
+	return b.kind == included
 }
 
-func newBound[K comparable](key K, flag boundFlag) bounder[K] {
-	switch flag {
+func (b Bound[K]) String() string {
+	switch b.kind {
 	case included:
-		return includedBound[K]{
-			baseBound[K]{
-				_key: key,
-			},
-		}
+		return fmt.Sprintf("Included(%v)", b.key)
 	case excluded:
-		return excludedBound[K]{
-			baseBound[K]{
-				_key: key,
-			},
-		}
+		return fmt.Sprintf("Excluded(%v)", b.key)
 	default:
-		panic("should not call me")
+		return "Unbounded"
 	}
 }
-
-func newUnbound[K comparable]() bounder[K] {
-	return unboundedBound[K]{}
-}
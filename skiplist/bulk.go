@@ -0,0 +1,55 @@
+package skiplist
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+
+	"github.com/crrow/reona/util"
+)
+
+// ErrUnsorted The keys passed to FromSorted are not in strictly ascending order.
+var ErrUnsorted = errors.New("skiplist: keys are not in strictly ascending order")
+
+// FromSorted Returns a skip list holding the entries of it, whose keys must be in strictly
+// ascending order, see Config for the options.
+func FromSorted[K cmp.Ordered, V any](it Iterator[K, V], opts ...util.Option[Config]) (*SkipList[K, V], error) {
+	return FromSortedFunc[K, V](cmp.Compare[K], it, opts...)
+}
+
+// FromSortedFunc Returns a skip list ordered by compare holding the entries of it, whose
+// keys must be in strictly ascending order, see NewFunc.
+//
+// Every entry is appended behind the previous one at each level of its tower, which takes
+// a single pass without searches or CAS loops. The skip list is not shared before it is
+// returned, so plain stores do. If a key is out of order, the error wraps ErrUnsorted.
+func FromSortedFunc[K any, V any](compare func(a, b K) int, it Iterator[K, V], opts ...util.Option[Config]) (*SkipList[K, V], error) {
+	sl := NewFunc[K, V](compare, opts...)
+	// the last tower at every level
+	var tails [maxHeight]*Tower[K, V]
+	for level := range tails {
+		tails[level] = &sl.head
+	}
+	var prev *Node[K, V]
+	var count uint64
+	height := 1
+	for it.Next() {
+		key := it.Key()
+		if prev != nil && compare(prev.key, key) >= 0 {
+			return nil, fmt.Errorf("%w: %v after %v", ErrUnsorted, key, prev.key)
+		}
+		h := sl.heights.height()
+		// The reference count is the number of levels the node is linked at.
+		n := newNode(h, key, it.Value(), uint64(h))
+		for level := 0; level < h; level++ {
+			tails[level].pointers[level].Store(n.ref(false))
+			tails[level] = &n.tower
+		}
+		height = max(height, h)
+		prev = n
+		count++
+	}
+	sl.len.Store(count)
+	sl.maxHeight.Store(uint64(height))
+	return sl, nil
+}
@@ -0,0 +1,83 @@
+package skiplist
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// sliceIter Yields the keys of a slice, with the keys times ten as values.
+type sliceIter struct {
+	keys []int
+	i    int
+}
+
+func (it *sliceIter) Next() bool {
+	it.i++
+	return it.i <= len(it.keys)
+}
+
+func (it *sliceIter) Key() int   { return it.keys[it.i-1] }
+func (it *sliceIter) Value() int { return it.keys[it.i-1] * 10 }
+
+func TestFromSorted(t *testing.T) {
+	var in []int
+	for i := 0; i < 5000; i += 3 {
+		in = append(in, i)
+	}
+	list, err := FromSorted[int, int](&sliceIter{keys: in})
+	assert.NoError(t, err)
+	assert.Equal(t, in, keys(list))
+	assert.EqualValues(t, len(in), list.Len())
+	checkTowers(t, list)
+	checkRefs(t, list)
+
+	// the result is a regular skip list
+	v, ok := list.Get(300)
+	assert.True(t, ok)
+	assert.Equal(t, 3000, v)
+	list.Insert(1, 10)
+	assert.True(t, list.Remove(0))
+	assert.Equal(t, []int{1, 3, 6}, collect(list.Range(Unbounded[int](), Excluded(9))))
+	assert.Equal(t, []int{4998, 4995}, collect(list.DescendRange(Included(4995), Unbounded[int]())))
+	checkTowers(t, list)
+
+	// and can be copied
+	copied, err := FromSorted[int, int](list.Range(Unbounded[int](), Unbounded[int]()), WithMaxHeight(4))
+	assert.NoError(t, err)
+	assert.Equal(t, keys(list), keys(copied))
+	checkTowers(t, copied)
+
+	empty, err := FromSorted[int, int](&sliceIter{})
+	assert.NoError(t, err)
+	assert.True(t, empty.IsEmpty())
+	empty.Insert(1, 1)
+	assert.Equal(t, []int{1}, keys(empty))
+
+	for _, bad := range [][]int{{1, 3, 2}, {1, 1}} {
+		_, err = FromSorted[int, int](&sliceIter{keys: bad})
+		assert.ErrorIs(t, err, ErrUnsorted)
+	}
+	_, err = FromSortedFunc[int, int](func(a, b int) int { return b - a }, &sliceIter{keys: []int{3, 2, 1}})
+	assert.NoError(t, err)
+}
+
+func BenchmarkFromSorted(b *testing.B) {
+	in := make([]int, 1<<16)
+	for i := range in {
+		in[i] = i
+	}
+	b.Run("FromSorted", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, _ = FromSorted[int, int](&sliceIter{keys: in})
+		}
+	})
+	b.Run("Insert", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			list := New[int, int]()
+			for _, k := range in {
+				list.Insert(k, k)
+			}
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package skiplist
+
+import (
+	"cmp"
+	"io"
+
+	"github.com/crrow/reona/dump"
+	"github.com/crrow/reona/util"
+)
+
+// Dump Writes the entries of the skip list to w in the format of package dump, in ascending
+// key order, and returns the number of entries written.
+//
+// The entries are streamed while iterating, without copying the skip list, and the dump is
+// as weakly consistent as Iter: it is safe while the skip list is modified, holds every
+// entry present for the whole dump exactly once, and may or may not hold entries inserted
+// or removed meanwhile. It is not a snapshot as of a single point in time.
+func (sl *SkipList[K, V]) Dump(w io.Writer, keys dump.Codec[K], values dump.Codec[V]) (uint64, error) {
+	dw := dump.NewWriter(w, keys, values)
+	var n uint64
+	for it := sl.Range(Unbounded[K](), Unbounded[K]()); it.Next(); n++ {
+		if err := dw.Write(it.Key(), it.Value()); err != nil {
+			return n, err
+		}
+	}
+	return n, dw.Close()
+}
+
+// Load Returns a skip list holding the entries of a dump read from r, ordered by the natural
+// order of the keys, see Config for the options.
+func Load[K cmp.Ordered, V any](r io.Reader, keys dump.Codec[K], values dump.Codec[V], opts ...util.Option[Config]) (*SkipList[K, V], error) {
+	return LoadFunc[K, V](cmp.Compare[K], r, keys, values, opts...)
+}
+
+// LoadFunc Returns a skip list ordered by compare holding the entries of a dump read from r,
+// see NewFunc.
+//
+// The dump must come from a skip list with the same order, it is built in one pass like
+// FromSortedFunc. Nothing is returned unless the whole dump was read and its checksum
+// matches.
+func LoadFunc[K any, V any](compare func(a, b K) int, r io.Reader, keys dump.Codec[K], values dump.Codec[V], opts ...util.Option[Config]) (*SkipList[K, V], error) {
+	dr := dump.NewReader(r, keys, values)
+	sl, err := FromSortedFunc[K, V](compare, dr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := dr.Err(); err != nil {
+		return nil, err
+	}
+	return sl, nil
+}
@@ -0,0 +1,76 @@
+package skiplist
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/crrow/reona/dump"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDumpLoad(t *testing.T) {
+	list := New[string, int]()
+	for i, k := range []string{"b", "a", "c", ""} {
+		list.Insert(k, i)
+	}
+	var buf bytes.Buffer
+	n, err := list.Dump(&buf, dump.String[string](), dump.Int[int]())
+	assert.NoError(t, err)
+	assert.EqualValues(t, 4, n)
+
+	loaded, err := Load(bytes.NewReader(buf.Bytes()), dump.String[string](), dump.Int[int]())
+	assert.NoError(t, err)
+	assert.Equal(t, keys(list), keys(loaded))
+	assert.Equal(t, list.Len(), loaded.Len())
+	v, ok := loaded.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+
+	// A dump in another order does not load.
+	_, err = LoadFunc(func(a, b string) int { return strings.Compare(b, a) }, bytes.NewReader(buf.Bytes()), dump.String[string](), dump.Int[int]())
+	assert.ErrorIs(t, err, ErrUnsorted)
+
+	_, err = Load(bytes.NewReader(buf.Bytes()[:buf.Len()-1]), dump.String[string](), dump.Int[int]())
+	assert.ErrorIs(t, err, dump.ErrCorrupt)
+}
+
+func TestDumpConcurrent(t *testing.T) {
+	// Even keys stay for the whole dump while odd keys come and go.
+	list := New[int, int]()
+	for i := 0; i < 1000; i += 2 {
+		list.Insert(i, i)
+	}
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 1; ; i = (i + 2) % 1000 {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			list.Insert(i, i)
+			runtime.Gosched()
+			list.Remove(i)
+		}
+	}()
+
+	var buf bytes.Buffer
+	_, err := list.Dump(&buf, dump.Int[int](), dump.Int[int]())
+	close(done)
+	wg.Wait()
+	assert.NoError(t, err)
+
+	loaded, err := Load(&buf, dump.Int[int](), dump.Int[int]())
+	assert.NoError(t, err)
+	for i := 0; i < 1000; i += 2 {
+		v, ok := loaded.Get(i)
+		assert.True(t, ok)
+		assert.Equal(t, i, v)
+	}
+}
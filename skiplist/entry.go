@@ -0,0 +1,106 @@
+package skiplist
+
+// Entry A reference counted handle to an entry of a skip list.
+//
+// An entry stays readable while held, even if it is removed from the skip list meanwhile,
+// and Next and Prev keep working from its key. Every entry must be released exactly once
+// and must not be used after that.
+type Entry[K any, V any] struct {
+	sl   *SkipList[K, V]
+	node *Node[K, V]
+}
+
+// Key Returns the key of the entry.
+func (e *Entry[K, V]) Key() K {
+	return e.node.key
+}
+
+// Value Returns the value of the entry.
+func (e *Entry[K, V]) Value() V {
+	return e.node.value
+}
+
+// IsRemoved Returns whether the entry has been removed from the skip list.
+func (e *Entry[K, V]) IsRemoved() bool {
+	return e.node.IsRemoved()
+}
+
+// Remove Removes the entry from the skip list, returns `false` if it was already removed.
+//
+// The entry stays valid and must still be released.
+func (e *Entry[K, V]) Remove() bool {
+	return e.sl.removeNode(e.node)
+}
+
+// Next Returns the entry with the least key greater than the key of e.
+func (e *Entry[K, V]) Next() (*Entry[K, V], bool) {
+	// If e was removed meanwhile, nextNode searches from its key.
+	return e.sl.acquire(func() *Node[K, V] {
+		return e.sl.nextNode(&e.node.tower, Excluded(e.node.key))
+	})
+}
+
+// Prev Returns the entry with the greatest key less than the key of e.
+func (e *Entry[K, V]) Prev() (*Entry[K, V], bool) {
+	return e.sl.acquire(func() *Node[K, V] {
+		return e.sl.searchBound(Excluded(e.node.key), true)
+	})
+}
+
+// Release Releases the reference to the entry.
+//
+// Once an entry is removed and unlinked, the last release drops the reference count to
+// zero and nothing keeps the node from being reclaimed.
+func (e *Entry[K, V]) Release() {
+	e.node.decrement()
+	e.node = nil
+}
+
+// GetEntry Returns the entry with the key, if it exists.
+func (sl *SkipList[K, V]) GetEntry(key K) (*Entry[K, V], bool) {
+	return sl.acquire(func() *Node[K, V] {
+		n := sl.searchBound(Included(key), false)
+		if n == nil || sl.compare(n.key, key) != 0 {
+			return nil
+		}
+		return n
+	})
+}
+
+// FrontEntry Returns the entry with the smallest key.
+func (sl *SkipList[K, V]) FrontEntry() (*Entry[K, V], bool) {
+	return sl.acquire(func() *Node[K, V] {
+		return sl.nextNode(&sl.head, Unbounded[K]())
+	})
+}
+
+// BackEntry Returns the entry with the largest key.
+func (sl *SkipList[K, V]) BackEntry() (*Entry[K, V], bool) {
+	return sl.acquire(func() *Node[K, V] {
+		return sl.searchBound(Unbounded[K](), true)
+	})
+}
+
+// InsertEntry Inserts a `key`-`value` pair into the skip list, replacing the existing
+// entry with this key, and returns the new entry.
+func (sl *SkipList[K, V]) InsertEntry(key K, value V) *Entry[K, V] {
+	// doInsert already holds a reference for us.
+	n, _ := sl.doInsert(key, func() V { return value }, true)
+	return &Entry[K, V]{sl: sl, node: n}
+}
+
+// acquire Returns an entry for the node found by search.
+//
+// A node whose reference count dropped to zero is unlinked from every level and about to
+// be reclaimed, so the search is repeated until it finds a node that can still be held.
+func (sl *SkipList[K, V]) acquire(search func() *Node[K, V]) (*Entry[K, V], bool) {
+	for {
+		n := search()
+		if n == nil {
+			return nil, false
+		}
+		if n.tryIncrement() {
+			return &Entry[K, V]{sl: sl, node: n}, true
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package skiplist
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// refs Returns the reference count of a node.
+func refs[K any, V any](n *Node[K, V]) uint64 {
+	return n.refsAndHeight.Load() >> heightBits
+}
+
+// checkRefs verifies that the reference count of every linked node equals the number of
+// levels it is linked at, that is no entry is held any more.
+func checkRefs[V any](t *testing.T, sl *SkipList[int, V]) {
+	linked := map[*Node[int, V]]uint64{}
+	for level := range sl.head.pointers {
+		for n := sl.head.pointers[level].Load().ptr(); n != nil; n = n.tower.pointers[level].Load().ptr() {
+			linked[n]++
+		}
+	}
+	for n, levels := range linked {
+		assert.Equal(t, levels, refs(n), "key %d", n.key)
+	}
+}
+
+func TestEntry(t *testing.T) {
+	list := New[int, string]()
+	_, ok := list.FrontEntry()
+	assert.False(t, ok)
+	for i, v := range []string{"a", "b", "c", "d"} {
+		list.Insert(i, v)
+	}
+
+	e, ok := list.GetEntry(1)
+	assert.True(t, ok)
+	assert.Equal(t, 1, e.Key())
+	assert.Equal(t, "b", e.Value())
+	assert.Equal(t, uint64(e.node.Height())+1, refs(e.node))
+
+	next, ok := e.Next()
+	assert.True(t, ok)
+	assert.Equal(t, 2, next.Key())
+	prev, ok := e.Prev()
+	assert.True(t, ok)
+	assert.Equal(t, 0, prev.Key())
+	_, ok = prev.Prev()
+	assert.False(t, ok)
+	prev.Release()
+
+	// the held entry outlives its removal
+	node := e.node
+	assert.True(t, list.Remove(1))
+	assert.True(t, e.IsRemoved())
+	assert.Equal(t, "b", e.Value())
+	assert.EqualValues(t, 1, refs(node))
+	_, ok = list.GetEntry(1)
+	assert.False(t, ok)
+	// and still knows where it was
+	assert.True(t, list.Remove(2))
+	list.Insert(1, "x")
+	n2, ok := e.Next()
+	assert.True(t, ok)
+	assert.Equal(t, 3, n2.Key())
+	p2, ok := e.Prev()
+	assert.True(t, ok)
+	assert.Equal(t, 0, p2.Key())
+	n2.Release()
+	p2.Release()
+	e.Release()
+	// nobody holds the node any more
+	assert.EqualValues(t, 0, refs(node))
+	assert.False(t, node.tryIncrement())
+
+	assert.True(t, next.IsRemoved())
+	assert.False(t, next.Remove())
+	next.Release()
+
+	back, ok := list.BackEntry()
+	assert.True(t, ok)
+	assert.Equal(t, 3, back.Key())
+	assert.True(t, back.Remove())
+	assert.False(t, back.Remove())
+	back.Release()
+
+	ins := list.InsertEntry(5, "e")
+	assert.Equal(t, "e", ins.Value())
+	ins.Release()
+	assert.Equal(t, []int{0, 1, 5}, keys(list))
+	checkRefs(t, list)
+}
+
+func TestEntryConcurrent(t *testing.T) {
+	const n = 512
+	list := New[int, int]()
+	for i := 0; i < n; i++ {
+		list.Insert(i, i)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(4)
+	// walkers move through the skip list holding one entry at a time, removing odd keys
+	for w := 0; w < 2; w++ {
+		go func(w int) {
+			defer wg.Done()
+			for round := 0; round < 20; round++ {
+				prev := -1
+				for e, ok := list.FrontEntry(); ok; {
+					assert.Greater(t, e.Key(), prev)
+					assert.Equal(t, e.Key(), e.Value())
+					prev = e.Key()
+					if e.Key()%2 == 1 && (e.Key()/2)%2 == w {
+						e.Remove()
+					}
+					var next *Entry[int, int]
+					next, ok = e.Next()
+					e.Release()
+					e = next
+				}
+			}
+		}(w)
+	}
+	// writers churn odd keys and walk backwards
+	for w := 0; w < 2; w++ {
+		go func(w int) {
+			defer wg.Done()
+			for round := 0; round < 2000; round++ {
+				k := (round*7919+w)%n | 1
+				list.Insert(k, k)
+				if e, ok := list.GetEntry(k - 1); ok {
+					if p, ok := e.Prev(); ok {
+						assert.Less(t, p.Key(), k-1)
+						p.Release()
+					}
+					e.Release()
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for i := 0; i < n; i += 2 {
+		v, ok := list.Get(i)
+		assert.True(t, ok)
+		assert.Equal(t, i, v)
+	}
+	checkTowers(t, list)
+	checkRefs(t, list)
+}
@@ -0,0 +1,128 @@
+package skiplist
+
+import (
+	"cmp"
+	"time"
+
+	"github.com/crrow/reona/util"
+)
+
+// Clock Tells the current time to an ExpiryIndex, tests inject a fake one instead of
+// sleeping.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// ExpiryIndex A lock-free index of items by deadline, on top of SkipList.
+//
+// Items are keyed by their deadline and an id, so an id can be scheduled at several
+// deadlines and items with the same deadline are ordered by id. Expired items are taken
+// from the front of the skip list, which stays cheap while producers keep adding.
+//
+// The empty value is not usable, use NewExpiryIndex or NewExpiryIndexFunc.
+type ExpiryIndex[ID any, V any] struct {
+	sl    *SkipList[expiryKey[ID], V]
+	clock Clock
+}
+
+type expiryKey[ID any] struct {
+	deadline time.Time
+	id       ID
+}
+
+// Expired An item taken from an ExpiryIndex.
+type Expired[ID any, V any] struct {
+	ID       ID
+	Deadline time.Time
+	Value    V
+}
+
+// NewExpiryIndex Returns an empty expiry index reading the time from clock, the system
+// clock if it is nil, see Config for the options.
+func NewExpiryIndex[ID cmp.Ordered, V any](clock Clock, opts ...util.Option[Config]) *ExpiryIndex[ID, V] {
+	return NewExpiryIndexFunc[ID, V](cmp.Compare[ID], clock, opts...)
+}
+
+// NewExpiryIndexFunc Returns an empty expiry index whose ids are ordered by compare, see
+// NewExpiryIndex.
+func NewExpiryIndexFunc[ID any, V any](compare func(a, b ID) int, clock Clock, opts ...util.Option[Config]) *ExpiryIndex[ID, V] {
+	if clock == nil {
+		clock = systemClock{}
+	}
+	return &ExpiryIndex[ID, V]{
+		sl: NewFunc[expiryKey[ID], V](func(a, b expiryKey[ID]) int {
+			if c := a.deadline.Compare(b.deadline); c != 0 {
+				return c
+			}
+			return compare(a.id, b.id)
+		}, opts...),
+		clock: clock,
+	}
+}
+
+// Len Returns the number of pending items.
+//
+// If the index is being concurrently modified, consider the returned number just an
+// approximation without any guarantees.
+func (x *ExpiryIndex[ID, V]) Len() uint64 {
+	return x.sl.Len()
+}
+
+// Add Schedules the item id to expire at deadline, replacing the value of an item with the
+// same id and deadline.
+func (x *ExpiryIndex[ID, V]) Add(id ID, deadline time.Time, value V) {
+	x.sl.Insert(expiryKey[ID]{deadline: deadline, id: id}, value)
+}
+
+// AddAfter Schedules the item id to expire ttl from now on the clock, and returns its
+// deadline.
+func (x *ExpiryIndex[ID, V]) AddAfter(id ID, ttl time.Duration, value V) time.Time {
+	deadline := x.clock.Now().Add(ttl)
+	x.Add(id, deadline, value)
+	return deadline
+}
+
+// Cancel Removes the item id scheduled at deadline, returns `false` if there was none,
+// because it expired already for instance.
+func (x *ExpiryIndex[ID, V]) Cancel(id ID, deadline time.Time) bool {
+	return x.sl.Remove(expiryKey[ID]{deadline: deadline, id: id})
+}
+
+// NextDeadline Returns the earliest deadline of the pending items.
+func (x *ExpiryIndex[ID, V]) NextDeadline() (time.Time, bool) {
+	n, ok := x.sl.Front()
+	if !ok {
+		return time.Time{}, false
+	}
+	return n.key.deadline, true
+}
+
+// PopExpired Removes the items whose deadline is at or before now and returns them,
+// earliest first.
+//
+// If several goroutines pop or cancel concurrently, every item is returned to or cancelled
+// by exactly one of them. Items added meanwhile with a deadline at or before now may be
+// left for the next call.
+func (x *ExpiryIndex[ID, V]) PopExpired(now time.Time) []Expired[ID, V] {
+	var expired []Expired[ID, V]
+	for {
+		n, ok := x.sl.Front()
+		if !ok || n.key.deadline.After(now) {
+			return expired
+		}
+		// Someone else took the node if this fails, look at the new front.
+		if x.sl.removeNode(n) {
+			expired = append(expired, Expired[ID, V]{ID: n.key.id, Deadline: n.key.deadline, Value: n.value})
+		}
+	}
+}
+
+// Expire Removes the items whose deadline has passed on the clock and returns them, see
+// PopExpired.
+func (x *ExpiryIndex[ID, V]) Expire() []Expired[ID, V] {
+	return x.PopExpired(x.clock.Now())
+}
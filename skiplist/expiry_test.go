@@ -0,0 +1,112 @@
+package skiplist
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func expiredIDs[V any](items []Expired[string, V]) []string {
+	var ids []string
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	return ids
+}
+
+func TestExpiryIndex(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	x := NewExpiryIndex[string, int](clock)
+	_, ok := x.NextDeadline()
+	assert.False(t, ok)
+	assert.Empty(t, x.Expire())
+
+	a := x.AddAfter("a", 10*time.Second, 1)
+	x.AddAfter("b", 5*time.Second, 2)
+	x.AddAfter("c", 10*time.Second, 3)
+	// the same id at another deadline is another item
+	x.AddAfter("a", time.Minute, 4)
+	assert.EqualValues(t, 4, x.Len())
+
+	next, ok := x.NextDeadline()
+	assert.True(t, ok)
+	assert.Equal(t, clock.now.Add(5*time.Second), next)
+
+	clock.now = clock.now.Add(4 * time.Second)
+	assert.Empty(t, x.Expire())
+
+	clock.now = clock.now.Add(6 * time.Second)
+	assert.True(t, x.Cancel("c", a))
+	assert.False(t, x.Cancel("c", a))
+	expired := x.Expire()
+	assert.Equal(t, []string{"b", "a"}, expiredIDs(expired))
+	assert.Equal(t, Expired[string, int]{ID: "a", Deadline: a, Value: 1}, expired[1])
+	assert.False(t, x.Cancel("a", a))
+
+	next, _ = x.NextDeadline()
+	assert.Equal(t, time.Unix(1060, 0), next)
+	assert.Equal(t, []string{"a"}, expiredIDs(x.PopExpired(next)))
+	assert.True(t, x.sl.IsEmpty())
+}
+
+func TestExpiryIndexConcurrent(t *testing.T) {
+	// Producers keep adding while consumers pop, every item is popped exactly once.
+	const producers, consumers, n = 4, 4, 500
+	start := time.Unix(0, 0)
+	x := NewExpiryIndexFunc[[2]int, int](func(a, b [2]int) int {
+		if a[0] != b[0] {
+			return a[0] - b[0]
+		}
+		return a[1] - b[1]
+	}, nil)
+
+	var producing sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		producing.Add(1)
+		go func(p int) {
+			defer producing.Done()
+			for i := 0; i < n; i++ {
+				x.Add([2]int{p, i}, start.Add(time.Duration(i)*time.Millisecond), i)
+			}
+		}(p)
+	}
+	var done sync.WaitGroup
+	popped := make([][]Expired[[2]int, int], consumers)
+	stop := make(chan struct{})
+	for c := 0; c < consumers; c++ {
+		done.Add(1)
+		go func(c int) {
+			defer done.Done()
+			for {
+				select {
+				case <-stop:
+					popped[c] = append(popped[c], x.PopExpired(start.Add(time.Hour))...)
+					return
+				default:
+					popped[c] = append(popped[c], x.PopExpired(start.Add(time.Hour))...)
+				}
+			}
+		}(c)
+	}
+	producing.Wait()
+	close(stop)
+	done.Wait()
+
+	seen := map[[2]int]bool{}
+	for _, items := range popped {
+		for _, item := range items {
+			assert.False(t, seen[item.ID], item.ID)
+			seen[item.ID] = true
+		}
+	}
+	assert.Len(t, seen, producers*n)
+	assert.True(t, x.sl.IsEmpty())
+}
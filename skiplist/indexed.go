@@ -0,0 +1,226 @@
+package skiplist
+
+import (
+	"cmp"
+	"sync"
+
+	"github.com/crrow/reona/util"
+)
+
+// Indexed An order-statistic skip list: besides lookups by key it finds the position of a
+// key and the key at a position in O(log n).
+//
+// Every link remembers its span, the number of level 0 steps it skips, like the sorted sets
+// of Redis. Updating spans cannot be done with single CAS operations, so Indexed is guarded
+// by a sync.RWMutex: reads run in parallel, writes are exclusive.
+//
+// The empty value is not usable, use NewIndexed or NewIndexedFunc.
+type Indexed[K any, V any] struct {
+	mu sync.RWMutex
+	// The head of the skip list (just a dummy node, not a real entry).
+	head indexedNode[K, V]
+	// Orders the keys.
+	compare func(a, b K) int
+	// Generates the heights of new towers.
+	heights heightGen
+	// The number of entries in the skip list.
+	len int
+	// the highest tower currently in use.
+	level int
+}
+
+type indexedNode[K any, V any] struct {
+	key   K
+	value V
+	next  []indexedLink[K, V]
+}
+
+// indexedLink A link to the next node at some level.
+type indexedLink[K any, V any] struct {
+	node *indexedNode[K, V]
+	// the number of nodes between the two ends of the link, counting the next node but not
+	// this one.
+	span int
+}
+
+// NewIndexed Returns an empty indexed skip list ordered by the natural order of the keys,
+// see Config for the options.
+func NewIndexed[K cmp.Ordered, V any](opts ...util.Option[Config]) *Indexed[K, V] {
+	return NewIndexedFunc[K, V](cmp.Compare[K], opts...)
+}
+
+// NewIndexedFunc Returns an empty indexed skip list ordered by compare, see NewFunc.
+func NewIndexedFunc[K any, V any](compare func(a, b K) int, opts ...util.Option[Config]) *Indexed[K, V] {
+	c := newConfig(opts...)
+	sl := &Indexed[K, V]{
+		head:    indexedNode[K, V]{next: make([]indexedLink[K, V], c.maxHeight)},
+		compare: compare,
+		level:   1,
+	}
+	sl.heights.init(c)
+	return sl
+}
+
+// Len Returns the number of entries in the skip list.
+func (sl *Indexed[K, V]) Len() uint64 {
+	sl.mu.RLock()
+	defer sl.mu.RUnlock()
+	return uint64(sl.len)
+}
+
+// IsEmpty Returns `true` if the skip list is empty.
+func (sl *Indexed[K, V]) IsEmpty() bool {
+	return sl.Len() == 0
+}
+
+// Get Returns the value associated with the key, if it exists.
+func (sl *Indexed[K, V]) Get(key K) (V, bool) {
+	sl.mu.RLock()
+	defer sl.mu.RUnlock()
+	_, pred := sl.countWhile(func(k K) bool { return sl.compare(k, key) < 0 })
+	if n := pred.next[0].node; n != nil && sl.compare(n.key, key) == 0 {
+		return n.value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Insert Inserts a `key`-`value` pair into the skip list, replacing the existing entry
+// with this key, if any.
+func (sl *Indexed[K, V]) Insert(key K, value V) {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+
+	var left [maxHeight]*indexedNode[K, V]
+	// rank[level] is the position of left[level], the head being at position 0.
+	var rank [maxHeight]int
+	pred := &sl.head
+	for level := sl.level - 1; level >= 0; level-- {
+		if level < sl.level-1 {
+			rank[level] = rank[level+1]
+		}
+		for next := pred.next[level]; next.node != nil && sl.compare(next.node.key, key) < 0; next = pred.next[level] {
+			rank[level] += next.span
+			pred = next.node
+		}
+		left[level] = pred
+	}
+	if n := pred.next[0].node; n != nil && sl.compare(n.key, key) == 0 {
+		n.value = value
+		return
+	}
+
+	height := sl.heights.height()
+	for ; sl.level < height; sl.level++ {
+		left[sl.level] = &sl.head
+		rank[sl.level] = 0
+		sl.head.next[sl.level].span = sl.len
+	}
+	n := &indexedNode[K, V]{key: key, value: value, next: make([]indexedLink[K, V], height)}
+	for level := 0; level < height; level++ {
+		l := &left[level].next[level]
+		// the new node splits the link of its predecessor in two
+		skipped := rank[0] - rank[level]
+		n.next[level] = indexedLink[K, V]{node: l.node, span: l.span - skipped}
+		*l = indexedLink[K, V]{node: n, span: skipped + 1}
+	}
+	// links above the new tower skip one more node now
+	for level := height; level < sl.level; level++ {
+		left[level].next[level].span++
+	}
+	sl.len++
+}
+
+// Remove Removes the entry with the key, returns `false` if there was none.
+func (sl *Indexed[K, V]) Remove(key K) bool {
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+
+	var left [maxHeight]*indexedNode[K, V]
+	pred := &sl.head
+	for level := sl.level - 1; level >= 0; level-- {
+		for next := pred.next[level].node; next != nil && sl.compare(next.key, key) < 0; next = pred.next[level].node {
+			pred = next
+		}
+		left[level] = pred
+	}
+	n := pred.next[0].node
+	if n == nil || sl.compare(n.key, key) != 0 {
+		return false
+	}
+
+	for level := 0; level < sl.level; level++ {
+		l := &left[level].next[level]
+		if l.node == n {
+			*l = indexedLink[K, V]{node: n.next[level].node, span: l.span + n.next[level].span - 1}
+		} else {
+			l.span--
+		}
+	}
+	for sl.level > 1 && sl.head.next[sl.level-1].node == nil {
+		sl.level--
+	}
+	sl.len--
+	return true
+}
+
+// Rank Returns the position of the key in ascending order, starting at 0, and whether the
+// key exists.
+//
+// For a missing key the position is where it would be inserted, the number of smaller
+// keys.
+func (sl *Indexed[K, V]) Rank(key K) (int, bool) {
+	sl.mu.RLock()
+	defer sl.mu.RUnlock()
+	rank, pred := sl.countWhile(func(k K) bool { return sl.compare(k, key) < 0 })
+	n := pred.next[0].node
+	return rank, n != nil && sl.compare(n.key, key) == 0
+}
+
+// Select Returns the entry at position i in ascending order, starting at 0.
+func (sl *Indexed[K, V]) Select(i int) (K, V, bool) {
+	sl.mu.RLock()
+	defer sl.mu.RUnlock()
+	if i < 0 || i >= sl.len {
+		var key K
+		var value V
+		return key, value, false
+	}
+	// positions count from the head at 0, so the entry at i is at i+1
+	traversed := 0
+	pred := &sl.head
+	for level := sl.level - 1; level >= 0; level-- {
+		for next := pred.next[level]; next.node != nil && traversed+next.span <= i+1; next = pred.next[level] {
+			traversed += next.span
+			pred = next.node
+		}
+		if traversed == i+1 {
+			break
+		}
+	}
+	return pred.key, pred.value, true
+}
+
+// Count Returns the number of entries with keys between lo and hi.
+func (sl *Indexed[K, V]) Count(lo, hi Bound[K]) int {
+	sl.mu.RLock()
+	defer sl.mu.RUnlock()
+	below, _ := sl.countWhile(func(k K) bool { return !aboveLowerBound(sl.compare, lo, k) })
+	upTo, _ := sl.countWhile(func(k K) bool { return belowUpperBound(sl.compare, hi, k) })
+	return max(upTo-below, 0)
+}
+
+// countWhile Returns the number of keys that satisfy pred, and the node of the last one.
+//
+// pred must hold for a prefix of the keys.
+func (sl *Indexed[K, V]) countWhile(pred func(K) bool) (int, *indexedNode[K, V]) {
+	rank := 0
+	curr := &sl.head
+	for level := sl.level - 1; level >= 0; level-- {
+		for next := curr.next[level]; next.node != nil && pred(next.node.key); next = curr.next[level] {
+			rank += next.span
+			curr = next.node
+		}
+	}
+	return rank, curr
+}
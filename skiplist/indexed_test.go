@@ -0,0 +1,134 @@
+package skiplist
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// checkSpans verifies that the span of every link equals the number of level 0 steps it
+// skips.
+func checkSpans[V any](t *testing.T, sl *Indexed[int, V]) {
+	pos := map[*indexedNode[int, V]]int{&sl.head: 0}
+	i := 0
+	for n := sl.head.next[0].node; n != nil; n = n.next[0].node {
+		i++
+		pos[n] = i
+	}
+	assert.Equal(t, sl.len, i)
+	for level := 0; level < sl.level; level++ {
+		for n := &sl.head; n.next[level].node != nil; n = n.next[level].node {
+			assert.Equal(t, pos[n.next[level].node]-pos[n], n.next[level].span, "level %d", level)
+		}
+	}
+}
+
+func TestIndexed(t *testing.T) {
+	list := NewIndexed[int, int](WithSeed(3))
+	assert.True(t, list.IsEmpty())
+	_, _, ok := list.Select(0)
+	assert.False(t, ok)
+	rank, ok := list.Rank(5)
+	assert.Equal(t, 0, rank)
+	assert.False(t, ok)
+
+	for _, k := range []int{50, 10, 40, 20, 30} {
+		list.Insert(k, k*10)
+	}
+	list.Insert(20, 7)
+	assert.EqualValues(t, 5, list.Len())
+	v, ok := list.Get(20)
+	assert.True(t, ok)
+	assert.Equal(t, 7, v)
+
+	for i, k := range []int{10, 20, 30, 40, 50} {
+		rank, ok := list.Rank(k)
+		assert.Equal(t, i, rank)
+		assert.True(t, ok)
+		key, _, ok := list.Select(i)
+		assert.True(t, ok)
+		assert.Equal(t, k, key)
+	}
+	rank, ok = list.Rank(35)
+	assert.Equal(t, 3, rank)
+	assert.False(t, ok)
+	_, _, ok = list.Select(5)
+	assert.False(t, ok)
+	_, _, ok = list.Select(-1)
+	assert.False(t, ok)
+
+	assert.Equal(t, 5, list.Count(Unbounded[int](), Unbounded[int]()))
+	assert.Equal(t, 3, list.Count(Included(20), Included(40)))
+	assert.Equal(t, 1, list.Count(Excluded(20), Excluded(40)))
+	assert.Equal(t, 2, list.Count(Included(15), Included(35)))
+	assert.Equal(t, 0, list.Count(Included(40), Included(20)))
+
+	assert.True(t, list.Remove(30))
+	assert.False(t, list.Remove(30))
+	_, ok = list.Get(30)
+	assert.False(t, ok)
+	rank, _ = list.Rank(40)
+	assert.Equal(t, 2, rank)
+	checkSpans(t, list)
+}
+
+func TestIndexedRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	list := NewIndexed[int, int](WithProbability(0.25))
+	present := map[int]bool{}
+	for i := 0; i < 20000; i++ {
+		k := rng.Intn(1000)
+		if rng.Intn(3) == 0 {
+			assert.Equal(t, present[k], list.Remove(k))
+			delete(present, k)
+		} else {
+			list.Insert(k, k)
+			present[k] = true
+		}
+		if i%1000 != 0 {
+			continue
+		}
+		var want []int
+		for k := range present {
+			want = append(want, k)
+		}
+		sort.Ints(want)
+		checkSpans(t, list)
+		for j, k := range want {
+			key, _, _ := list.Select(j)
+			assert.Equal(t, k, key)
+		}
+		q := rng.Intn(1000)
+		rank, ok := list.Rank(q)
+		assert.Equal(t, sort.SearchInts(want, q), rank)
+		assert.Equal(t, present[q], ok)
+		lo, hi := rng.Intn(1000), rng.Intn(1000)
+		assert.Equal(t, max(sort.SearchInts(want, hi)-sort.SearchInts(want, lo+1), 0), list.Count(Excluded(lo), Excluded(hi)))
+	}
+}
+
+func TestIndexedConcurrent(t *testing.T) {
+	const workers = 4
+	list := NewIndexed[int, int]()
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				k := i*workers + w
+				list.Insert(k, k)
+				if key, v, ok := list.Select(i / 2); ok {
+					assert.Equal(t, key, v)
+				}
+				list.Rank(k)
+			}
+		}(w)
+	}
+	wg.Wait()
+	assert.EqualValues(t, 1000*workers, list.Len())
+	checkSpans(t, list)
+}
@@ -0,0 +1,90 @@
+package skiplist
+
+// Iterator Yields entries one by one, Next advances to the next one and returns `false`
+// once there are no more.
+type Iterator[K any, V any] interface {
+	Next() bool
+	Key() K
+	Value() V
+}
+
+// Iter An iterator over a range of entries, in ascending or descending key order.
+//
+// Iterating is safe while the skip list is concurrently modified, but the iterator is
+// weakly consistent: entries that are present for the whole iteration are yielded exactly
+// once, entries inserted or removed meanwhile may or may not be. Keys are always yielded
+// in strictly increasing order, or strictly decreasing order for a descending iterator.
+type Iter[K any, V any] struct {
+	sl      *SkipList[K, V]
+	lo, hi  Bound[K]
+	reverse bool
+	// the node of the last yielded entry, nil before the first call to Next
+	curr *Node[K, V]
+	done bool
+}
+
+// Range Returns an iterator over the entries with keys between lo and hi.
+func (sl *SkipList[K, V]) Range(lo, hi Bound[K]) *Iter[K, V] {
+	return &Iter[K, V]{sl: sl, lo: lo, hi: hi}
+}
+
+// Descend Returns an iterator over the entries with keys below from, largest key first.
+func (sl *SkipList[K, V]) Descend(from Bound[K]) *Iter[K, V] {
+	return sl.DescendRange(Unbounded[K](), from)
+}
+
+// DescendRange Returns an iterator over the entries with keys between lo and hi, largest
+// key first.
+//
+// Nodes only link forward, so every step searches the predecessor of the current key
+// from the top of the skip list and costs O(log n), like a lookup.
+func (sl *SkipList[K, V]) DescendRange(lo, hi Bound[K]) *Iter[K, V] {
+	return &Iter[K, V]{sl: sl, lo: lo, hi: hi, reverse: true}
+}
+
+// Next Advances to the next entry and returns `false` once the range is exhausted.
+func (it *Iter[K, V]) Next() bool {
+	if it.done {
+		return false
+	}
+	if it.reverse {
+		return it.advance(it.prev())
+	}
+	return it.advance(it.next())
+}
+
+func (it *Iter[K, V]) next() *Node[K, V] {
+	if it.curr == nil {
+		return it.sl.searchBound(it.lo, false)
+	}
+	// If the current node was removed meanwhile, nextNode searches from its key.
+	return it.sl.nextNode(&it.curr.tower, Excluded(it.curr.key))
+}
+
+func (it *Iter[K, V]) prev() *Node[K, V] {
+	if it.curr == nil {
+		return it.sl.searchBound(it.hi, true)
+	}
+	return it.sl.searchBound(Excluded(it.curr.key), true)
+}
+
+// advance Moves to n if it is still in range.
+func (it *Iter[K, V]) advance(n *Node[K, V]) bool {
+	if n == nil || !belowUpperBound(it.sl.compare, it.hi, n.key) || !aboveLowerBound(it.sl.compare, it.lo, n.key) {
+		it.done = true
+		it.curr = nil
+		return false
+	}
+	it.curr = n
+	return true
+}
+
+// Key Returns the key of the current entry.
+func (it *Iter[K, V]) Key() K {
+	return it.curr.key
+}
+
+// Value Returns the value of the current entry.
+func (it *Iter[K, V]) Value() V {
+	return it.curr.value
+}
@@ -0,0 +1,169 @@
+package skiplist
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func collect[K int | string, V any](it *Iter[K, V]) []K {
+	var r []K
+	for it.Next() {
+		r = append(r, it.Key())
+	}
+	return r
+}
+
+func TestRange(t *testing.T) {
+	list := New[int, int]()
+	for i := 0; i < 10; i += 2 {
+		list.Insert(i, i*10)
+	}
+
+	for _, tc := range []struct {
+		lo, hi Bound[int]
+		want   []int
+	}{
+		{Unbounded[int](), Unbounded[int](), []int{0, 2, 4, 6, 8}},
+		{Included(2), Included(6), []int{2, 4, 6}},
+		{Excluded(2), Excluded(6), []int{4}},
+		{Included(1), Included(7), []int{2, 4, 6}},
+		{Excluded(8), Unbounded[int](), nil},
+		{Unbounded[int](), Excluded(0), nil},
+		{Included(6), Included(2), nil},
+		{Included(4), Included(4), []int{4}},
+		{Excluded(4), Included(4), nil},
+	} {
+		assert.Equal(t, tc.want, collect(list.Range(tc.lo, tc.hi)), "%v..%v", tc.lo, tc.hi)
+	}
+
+	it := list.Range(Included(4), Unbounded[int]())
+	assert.True(t, it.Next())
+	assert.Equal(t, 4, it.Key())
+	assert.Equal(t, 40, it.Value())
+	// removing the current entry does not derail the iterator
+	assert.True(t, list.Remove(4))
+	list.Insert(5, 50)
+	assert.True(t, it.Next())
+	assert.Equal(t, 5, it.Key())
+	assert.Equal(t, []int{6, 8}, collect(it))
+	assert.False(t, it.Next())
+}
+
+func TestBound(t *testing.T) {
+	k, ok := Included(3).Key()
+	assert.Equal(t, 3, k)
+	assert.True(t, ok)
+	assert.True(t, Included(3).IsIncluded())
+	assert.False(t, Excluded(3).IsIncluded())
+	_, ok = Unbounded[int]().Key()
+	assert.False(t, ok)
+	var zero Bound[int]
+	assert.Equal(t, Unbounded[int](), zero)
+	assert.Equal(t, "Excluded(3)", Excluded(3).String())
+}
+
+func TestRangeWhileModified(t *testing.T) {
+	const n = 2000
+	list := New[int, int]()
+	// even keys stay for the whole test, odd keys come and go
+	for i := 0; i < n; i += 2 {
+		list.Insert(i, i)
+	}
+
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	wg.Add(2)
+	for w := 0; w < 2; w++ {
+		go func(w int) {
+			defer wg.Done()
+			for round := 0; !stop.Load(); round++ {
+				k := (round*7919+w*31)%n | 1
+				list.Insert(k, k)
+				list.Remove(k)
+			}
+		}(w)
+	}
+
+	for round := 0; round < 20; round++ {
+		prev := -1
+		var evens []int
+		for it := list.Range(Unbounded[int](), Unbounded[int]()); it.Next(); {
+			assert.Greater(t, it.Key(), prev)
+			prev = it.Key()
+			if it.Key()%2 == 0 {
+				evens = append(evens, it.Key())
+			}
+		}
+		assert.Len(t, evens, n/2)
+	}
+	stop.Store(true)
+	wg.Wait()
+}
+
+func TestDescend(t *testing.T) {
+	list := New[int, int]()
+	assert.Nil(t, collect(list.Descend(Unbounded[int]())))
+	for i := 0; i < 10; i += 2 {
+		list.Insert(i, i*10)
+	}
+
+	assert.Equal(t, []int{8, 6, 4, 2, 0}, collect(list.Descend(Unbounded[int]())))
+	assert.Equal(t, []int{6, 4, 2, 0}, collect(list.Descend(Included(6))))
+	assert.Equal(t, []int{4, 2, 0}, collect(list.Descend(Excluded(6))))
+	assert.Equal(t, []int{4, 2, 0}, collect(list.Descend(Included(5))))
+	assert.Nil(t, collect(list.Descend(Excluded(0))))
+	assert.Equal(t, []int{6, 4}, collect(list.DescendRange(Excluded(2), Included(6))))
+	assert.Equal(t, []int{8, 6, 4, 2}, collect(list.DescendRange(Included(1), Unbounded[int]())))
+	assert.Nil(t, collect(list.DescendRange(Included(6), Included(2))))
+
+	it := list.Descend(Unbounded[int]())
+	assert.True(t, it.Next())
+	assert.Equal(t, 8, it.Key())
+	assert.Equal(t, 80, it.Value())
+	assert.True(t, list.Remove(8))
+	assert.True(t, list.Remove(6))
+	list.Insert(7, 70)
+	assert.True(t, it.Next())
+	assert.Equal(t, 7, it.Key())
+	assert.Equal(t, []int{4, 2, 0}, collect(it))
+}
+
+func TestDescendWhileModified(t *testing.T) {
+	const n = 2000
+	list := New[int, int]()
+	for i := 0; i < n; i += 2 {
+		list.Insert(i, i)
+	}
+
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	wg.Add(2)
+	for w := 0; w < 2; w++ {
+		go func(w int) {
+			defer wg.Done()
+			for round := 0; !stop.Load(); round++ {
+				k := (round*7919+w*31)%n | 1
+				list.Insert(k, k)
+				list.Remove(k)
+			}
+		}(w)
+	}
+
+	for round := 0; round < 20; round++ {
+		prev := n
+		var evens int
+		for it := list.Descend(Unbounded[int]()); it.Next(); {
+			assert.Less(t, it.Key(), prev)
+			prev = it.Key()
+			if it.Key()%2 == 0 {
+				evens++
+			}
+		}
+		assert.Equal(t, n/2, evens)
+	}
+	stop.Store(true)
+	wg.Wait()
+}
@@ -0,0 +1,239 @@
+// Package lazy implements the lazy skip list of Herlihy, Lev, Luchangco and Shavit, a
+// lock-based baseline for the lock-free skip list of package skiplist.
+//
+// Lookups take no locks and never retry. Insert and Remove search without locks too, then
+// lock only the predecessors they modify, validate that nothing changed meanwhile and
+// retry otherwise. A node is logically in the list once it is fully linked at every level
+// of its tower, and logically removed once it is marked, before it is unlinked.
+package lazy
+
+import (
+	"cmp"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/crrow/reona/skiplist"
+	"github.com/crrow/reona/util"
+)
+
+// maxHeight The tallest tower allowed by skiplist.WithMaxHeight.
+const maxHeight = 32
+
+type node[K any, V any] struct {
+	key   K
+	value atomic.Pointer[V]
+	next  []atomic.Pointer[node[K, V]]
+	// guards the links of the tower and marked
+	mu sync.Mutex
+	// the node was removed, set before it is unlinked
+	marked atomic.Bool
+	// the node is linked at every level of its tower
+	fullyLinked atomic.Bool
+}
+
+func (n *node[K, V]) height() int {
+	return len(n.next)
+}
+
+// SkipList A lazy skip list.
+//
+// The empty value is not usable, use New or NewFunc.
+type SkipList[K any, V any] struct {
+	// The head of the skip list (just a dummy node, not a real entry).
+	head    node[K, V]
+	compare func(a, b K) int
+	heights *skiplist.Heights
+	// The number of entries in the skip list.
+	len atomic.Uint64
+}
+
+var _ skiplist.Map[int, int] = (*SkipList[int, int])(nil)
+
+// New Returns an empty skip list ordered by the natural order of the keys, its towers
+// are set up by the same options as those of skiplist.New.
+func New[K cmp.Ordered, V any](opts ...util.Option[skiplist.Config]) *SkipList[K, V] {
+	return NewFunc[K, V](cmp.Compare[K], opts...)
+}
+
+// NewFunc Returns an empty skip list ordered by compare, see skiplist.NewFunc.
+func NewFunc[K any, V any](compare func(a, b K) int, opts ...util.Option[skiplist.Config]) *SkipList[K, V] {
+	heights := skiplist.NewHeights(opts...)
+	return &SkipList[K, V]{
+		head:    node[K, V]{next: make([]atomic.Pointer[node[K, V]], heights.Max())},
+		compare: compare,
+		heights: heights,
+	}
+}
+
+// Len Returns the number of entries in the skip list.
+func (sl *SkipList[K, V]) Len() uint64 {
+	return sl.len.Load()
+}
+
+// MemoryUsage Estimates the bytes held by the skip list, see skiplist.SkipList.MemoryUsage,
+// plus the boxed value of every entry.
+func (sl *SkipList[K, V]) MemoryUsage() uint64 {
+	var v V
+	slot := uint64(unsafe.Sizeof(atomic.Pointer[node[K, V]]{}))
+	r := uint64(unsafe.Sizeof(*sl)) + uint64(len(sl.head.next))*slot
+	for n := sl.head.next[0].Load(); n != nil; n = n.next[0].Load() {
+		r += uint64(unsafe.Sizeof(*n)+unsafe.Sizeof(v)) + uint64(len(n.next))*slot
+	}
+	return r
+}
+
+// find Fills in the predecessor and successor of key at every level and returns the
+// highest level at which the successor has key, -1 if none has.
+func (sl *SkipList[K, V]) find(key K, preds, succs *[maxHeight]*node[K, V]) int {
+	found := -1
+	pred := &sl.head
+	for level := len(sl.head.next) - 1; level >= 0; level-- {
+		curr := pred.next[level].Load()
+		for curr != nil && sl.compare(curr.key, key) < 0 {
+			pred = curr
+			curr = pred.next[level].Load()
+		}
+		if found == -1 && curr != nil && sl.compare(curr.key, key) == 0 {
+			found = level
+		}
+		preds[level] = pred
+		succs[level] = curr
+	}
+	return found
+}
+
+// Get Returns the value associated with the key, if it exists.
+func (sl *SkipList[K, V]) Get(key K) (V, bool) {
+	pred := &sl.head
+	for level := len(sl.head.next) - 1; level >= 0; level-- {
+		curr := pred.next[level].Load()
+		for curr != nil && sl.compare(curr.key, key) < 0 {
+			pred = curr
+			curr = pred.next[level].Load()
+		}
+		if curr != nil && sl.compare(curr.key, key) == 0 {
+			if curr.fullyLinked.Load() && !curr.marked.Load() {
+				return *curr.value.Load(), true
+			}
+			break
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+// Insert Inserts a `key`-`value` pair into the skip list, replacing the existing entry
+// with this key, if any.
+func (sl *SkipList[K, V]) Insert(key K, value V) {
+	height := sl.heights.Next()
+	var preds, succs [maxHeight]*node[K, V]
+	for {
+		if found := sl.find(key, &preds, &succs); found != -1 {
+			n := succs[found]
+			if !n.marked.Load() {
+				// The node is being inserted, it is ours to update once it is linked.
+				for !n.fullyLinked.Load() {
+					runtime.Gosched()
+				}
+				n.value.Store(&value)
+				return
+			}
+			// The node is being removed, wait for it to be unlinked.
+			runtime.Gosched()
+			continue
+		}
+
+		locked, valid := lockPreds(&preds, height, func(level int, pred *node[K, V]) bool {
+			succ := succs[level]
+			return !pred.marked.Load() && (succ == nil || !succ.marked.Load()) &&
+				pred.next[level].Load() == succ
+		})
+		if !valid {
+			unlockPreds(&preds, locked)
+			continue
+		}
+
+		n := &node[K, V]{key: key, next: make([]atomic.Pointer[node[K, V]], height)}
+		n.value.Store(&value)
+		for level := 0; level < height; level++ {
+			n.next[level].Store(succs[level])
+		}
+		for level := 0; level < height; level++ {
+			preds[level].next[level].Store(n)
+		}
+		n.fullyLinked.Store(true)
+		unlockPreds(&preds, locked)
+		sl.len.Add(1)
+		return
+	}
+}
+
+// Remove Removes the entry with the key, returns `false` if there was none.
+func (sl *SkipList[K, V]) Remove(key K) bool {
+	var victim *node[K, V]
+	var preds, succs [maxHeight]*node[K, V]
+	for {
+		found := sl.find(key, &preds, &succs)
+		if victim == nil {
+			// Only a fully linked node found at its top level is safe to remove, anything
+			// else is being inserted or removed.
+			if found == -1 {
+				return false
+			}
+			n := succs[found]
+			if !n.fullyLinked.Load() || n.height()-1 != found || n.marked.Load() {
+				return false
+			}
+			n.mu.Lock()
+			if n.marked.Load() {
+				n.mu.Unlock()
+				return false
+			}
+			n.marked.Store(true)
+			victim = n
+		}
+
+		locked, valid := lockPreds(&preds, victim.height(), func(level int, pred *node[K, V]) bool {
+			return !pred.marked.Load() && pred.next[level].Load() == victim
+		})
+		if !valid {
+			unlockPreds(&preds, locked)
+			continue
+		}
+
+		for level := victim.height() - 1; level >= 0; level-- {
+			preds[level].next[level].Store(victim.next[level].Load())
+		}
+		victim.mu.Unlock()
+		unlockPreds(&preds, locked)
+		sl.len.Add(^uint64(0))
+		return true
+	}
+}
+
+// lockPreds Locks the predecessors of the levels below height, bottom up, while valid
+// holds, and returns the number of levels locked.
+//
+// Adjacent levels often share a predecessor, which is locked once.
+func lockPreds[K any, V any](preds *[maxHeight]*node[K, V], height int, valid func(int, *node[K, V]) bool) (int, bool) {
+	for level := 0; level < height; level++ {
+		pred := preds[level]
+		if level == 0 || pred != preds[level-1] {
+			pred.mu.Lock()
+		}
+		if !valid(level, pred) {
+			return level + 1, false
+		}
+	}
+	return height, true
+}
+
+func unlockPreds[K any, V any](preds *[maxHeight]*node[K, V], locked int) {
+	for level := 0; level < locked; level++ {
+		if level == 0 || preds[level] != preds[level-1] {
+			preds[level].mu.Unlock()
+		}
+	}
+}
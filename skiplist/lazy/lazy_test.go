@@ -0,0 +1,102 @@
+package lazy
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/crrow/reona/skiplist"
+	"github.com/crrow/reona/util"
+	"github.com/stretchr/testify/assert"
+)
+
+// checkLevels verifies that every level is sorted, only links live, fully linked nodes and
+// that level 0 holds Len nodes.
+func checkLevels[V any](t *testing.T, sl *SkipList[int, V]) {
+	for level := 0; level < len(sl.head.next); level++ {
+		count := 0
+		prev := -1 << 63
+		for n := sl.head.next[level].Load(); n != nil; n = n.next[level].Load() {
+			assert.Greater(t, n.key, prev, "level %d is not sorted", level)
+			assert.Greater(t, n.height(), level)
+			assert.True(t, n.fullyLinked.Load())
+			assert.False(t, n.marked.Load(), "key %d", n.key)
+			prev = n.key
+			count++
+		}
+		if level == 0 {
+			assert.EqualValues(t, sl.Len(), count)
+		}
+	}
+}
+
+func TestLazy(t *testing.T) {
+	sl := New[int, string]()
+	_, ok := sl.Get(1)
+	assert.False(t, ok)
+	sl.Insert(2, "b")
+	sl.Insert(1, "a")
+	sl.Insert(2, "B")
+	v, ok := sl.Get(2)
+	assert.True(t, ok)
+	assert.Equal(t, "B", v)
+	assert.EqualValues(t, 2, sl.Len())
+	assert.True(t, sl.Remove(1))
+	assert.False(t, sl.Remove(1))
+	_, ok = sl.Get(1)
+	assert.False(t, ok)
+	checkLevels(t, sl)
+}
+
+func TestLazyConcurrent(t *testing.T) {
+	const workers = 8
+	sl := New[int, int]()
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func(w int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(int64(w)))
+			for i := 0; i < 5000; i++ {
+				k := rng.Intn(128)
+				switch rng.Intn(3) {
+				case 0:
+					sl.Insert(k, k)
+				case 1:
+					sl.Remove(k)
+				default:
+					if v, ok := sl.Get(k); ok {
+						assert.Equal(t, k, v)
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	checkLevels(t, sl)
+}
+
+func TestLazyOptions(t *testing.T) {
+	heights := func(opts ...util.Option[skiplist.Config]) []int {
+		sl := New[int, int](opts...)
+		var hs []int
+		for k := 0; k < 256; k++ {
+			sl.Insert(k, k)
+		}
+		for n := sl.head.next[0].Load(); n != nil; n = n.next[0].Load() {
+			hs = append(hs, n.height())
+		}
+		checkLevels(t, sl)
+		return hs
+	}
+
+	sl := New[int, int](skiplist.WithMaxHeight(3))
+	assert.Len(t, sl.head.next, 3)
+	for _, h := range heights(skiplist.WithMaxHeight(3)) {
+		assert.LessOrEqual(t, h, 3)
+	}
+	for _, h := range heights(skiplist.WithProbability(0)) {
+		assert.Equal(t, 1, h)
+	}
+	assert.Equal(t, heights(skiplist.WithSeed(7)), heights(skiplist.WithSeed(7)))
+}
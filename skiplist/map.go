@@ -0,0 +1,17 @@
+package skiplist
+
+// Map The operations every skip list map of this module supports, so that tests and
+// benchmarks can run identical workloads against each of them.
+type Map[K any, V any] interface {
+	// Get Returns the value associated with the key, if it exists.
+	Get(key K) (V, bool)
+	// Insert Inserts a `key`-`value` pair, replacing the existing entry with this key.
+	Insert(key K, value V)
+	// Remove Removes the entry with the key, returns `false` if there was none.
+	Remove(key K) bool
+	// Len Returns the number of entries.
+	Len() uint64
+}
+
+var _ Map[int, int] = (*SkipList[int, int])(nil)
+var _ Map[int, int] = (*Indexed[int, int])(nil)
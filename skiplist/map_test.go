@@ -0,0 +1,91 @@
+package skiplist_test
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/crrow/reona/skiplist"
+	"github.com/crrow/reona/skiplist/lazy"
+	"github.com/stretchr/testify/assert"
+)
+
+// maps Every skip list map, so that they are tested the same way.
+var maps = []struct {
+	name string
+	new  func() skiplist.Map[int, int]
+}{
+	{"lockfree", func() skiplist.Map[int, int] { return skiplist.New[int, int]() }},
+	{"lazy", func() skiplist.Map[int, int] { return lazy.New[int, int]() }},
+	{"indexed", func() skiplist.Map[int, int] { return skiplist.NewIndexed[int, int]() }},
+}
+
+func TestMaps(t *testing.T) {
+	for _, m := range maps {
+		t.Run(m.name, func(t *testing.T) {
+			sl := m.new()
+			model := map[int]int{}
+			rng := rand.New(rand.NewSource(1))
+			for i := 0; i < 20000; i++ {
+				k := rng.Intn(500)
+				switch rng.Intn(3) {
+				case 0:
+					sl.Insert(k, i)
+					model[k] = i
+				case 1:
+					_, ok := model[k]
+					assert.Equal(t, ok, sl.Remove(k))
+					delete(model, k)
+				default:
+					v, ok := sl.Get(k)
+					want, wantOK := model[k]
+					assert.Equal(t, wantOK, ok)
+					assert.Equal(t, want, v)
+				}
+			}
+			assert.EqualValues(t, len(model), sl.Len())
+		})
+	}
+}
+
+func TestMapsConcurrent(t *testing.T) {
+	const workers, n = 4, 2000
+	for _, m := range maps {
+		t.Run(m.name, func(t *testing.T) {
+			sl := m.new()
+			var wg sync.WaitGroup
+			wg.Add(workers)
+			for w := 0; w < workers; w++ {
+				go func(w int) {
+					defer wg.Done()
+					// every worker owns the keys equal to w modulo workers and fights over
+					// the shared negative ones
+					for i := 0; i < n; i++ {
+						own := i*workers + w
+						sl.Insert(own, own)
+						shared := -1 - i%64
+						sl.Insert(shared, w)
+						sl.Remove(shared)
+						if i%2 == 0 {
+							assert.True(t, sl.Remove(own))
+						}
+						v, ok := sl.Get(own)
+						assert.Equal(t, i%2 == 1, ok)
+						if ok {
+							assert.Equal(t, own, v)
+						}
+					}
+				}(w)
+			}
+			wg.Wait()
+			for k := 0; k < workers*n; k++ {
+				v, ok := sl.Get(k)
+				if ok {
+					assert.Equal(t, k, v)
+				}
+				assert.Equal(t, k/workers%2 == 1, ok, "key %d", k)
+			}
+			assert.EqualValues(t, workers*n/2, sl.Len())
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package skiplist
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/crrow/reona/util"
+)
+
+// ErrRecordExists The memtable already holds the internal key.
+var ErrRecordExists = errors.New("skiplist: record already exists")
+
+// Kind The type of a record in a memtable.
+type Kind uint8
+
+const (
+	// KindDelete A tombstone, the key was deleted at the sequence number.
+	KindDelete Kind = iota
+	// KindSet The key was set to the value at the sequence number.
+	KindSet
+)
+
+// MaxSeq The largest sequence number, the low byte of the trailer keeps the kind.
+const MaxSeq = 1<<56 - 1
+
+// trailerSize Sequence number and kind are encoded in 8 bytes after the user key.
+const trailerSize = 8
+
+// InternalKey A user key together with the sequence number and the kind of a record.
+//
+// Internal keys are ordered by user key, then by decreasing sequence number and kind, so
+// the newest record of a user key comes first.
+type InternalKey struct {
+	UserKey []byte
+	Seq     uint64
+	Kind    Kind
+}
+
+func (k InternalKey) String() string {
+	return fmt.Sprintf("%q#%d,%d", k.UserKey, k.Seq, k.Kind)
+}
+
+func (k InternalKey) trailer() uint64 {
+	return k.Seq<<8 | uint64(k.Kind)
+}
+
+// CompareInternalKeys Returns the order of two internal keys like bytes.Compare.
+func CompareInternalKeys(a, b InternalKey) int {
+	if c := bytes.Compare(a.UserKey, b.UserKey); c != 0 {
+		return c
+	}
+	return cmp.Compare(b.trailer(), a.trailer())
+}
+
+// Memtable A skip list of []byte keys and values allocated from an arena, meant as the
+// memtable of a log-structured storage engine.
+//
+// Records are never removed or overwritten, a deletion is a newer record of kind
+// KindDelete. Writers insert concurrently without locks, readers never block. Once the
+// arena is full, Add fails with ErrArenaFull and the memtable should be flushed and
+// replaced.
+//
+// Keys and values returned by the memtable point into the arena and must not be modified.
+//
+// Every node is laid out in the arena as
+//
+//	keySize | valueSize | height | tower[height] | user key | trailer | value
+//
+// where the first three are uint32s, the tower holds the uint32 offsets of the next
+// nodes and the trailer is the little endian uint64 seq<<8 | kind.
+type Memtable struct {
+	arena *arena
+	// the offset of the head node, whose tower is as high as the memtable allows
+	head uint32
+	// Generates the heights of new towers.
+	heights heightGen
+	// the highest tower currently in use, lookups start there.
+	height atomic.Uint32
+	// The number of records in the memtable.
+	len atomic.Uint64
+}
+
+const (
+	nodeKeySize   = 0
+	nodeValueSize = 4
+	nodeHeight    = 8
+	nodeTower     = 12
+)
+
+// NewMemtable Returns an empty memtable with an arena of capacity bytes, see Config for the
+// options.
+func NewMemtable(capacity uint32, opts ...util.Option[Config]) *Memtable {
+	m := &Memtable{arena: newArena(capacity)}
+	m.heights.init(newConfig(opts...))
+	head, err := m.newNode(InternalKey{}, nil, m.heights.maxHeight)
+	if err != nil {
+		panic(fmt.Sprintf("skiplist: memtable capacity %d is too small", capacity))
+	}
+	m.head = head
+	m.height.Store(1)
+	return m
+}
+
+// Len Returns the number of records in the memtable.
+func (m *Memtable) Len() uint64 {
+	return m.len.Load()
+}
+
+// ApproximateMemoryUsage Returns the number of arena bytes in use.
+func (m *Memtable) ApproximateMemoryUsage() uint64 {
+	return uint64(m.arena.size())
+}
+
+// Add Inserts a record, returns ErrRecordExists if the memtable already has the same
+// internal key and ErrArenaFull if the record does not fit.
+func (m *Memtable) Add(key InternalKey, value []byte) error {
+	if key.Seq > MaxSeq {
+		return fmt.Errorf("skiplist: sequence number %d is above MaxSeq", key.Seq)
+	}
+	// Find the predecessor and successor at every level before allocating anything, so
+	// that duplicates do not waste arena space.
+	var left, right [maxHeight]uint32
+	listHeight := int(m.height.Load())
+	pred := m.head
+	for level := listHeight - 1; level >= 0; level-- {
+		var found bool
+		left[level], right[level], found = m.findSplice(key, level, pred)
+		if found {
+			return ErrRecordExists
+		}
+		pred = left[level]
+	}
+
+	height := m.heights.height()
+	n, err := m.newNode(key, value, height)
+	if err != nil {
+		return err
+	}
+	for h := uint32(listHeight); h < uint32(height); h = m.height.Load() {
+		if m.height.CompareAndSwap(h, uint32(height)) {
+			break
+		}
+	}
+	for level := listHeight; level < height; level++ {
+		left[level], right[level] = m.head, nullOffset
+	}
+
+	for level := 0; level < height; level++ {
+		for {
+			m.next(n, level).Store(right[level])
+			if m.next(left[level], level).CompareAndSwap(right[level], n) {
+				break
+			}
+			// Someone linked another node at this level meanwhile, the splice can only
+			// have moved forward from the old predecessor.
+			var found bool
+			left[level], right[level], found = m.findSplice(key, level, left[level])
+			if found {
+				// Only reachable at level 0, where a concurrent Add of the same key
+				// won and n is never linked nor counted. Towers are linked bottom up
+				// and never unlinked, so once n is in level 0 no other node with the
+				// key can be: the other Add fails at level 0 instead. And n itself is
+				// not linked at the level being searched yet.
+				return ErrRecordExists
+			}
+		}
+	}
+	m.len.Add(1)
+	return nil
+}
+
+// Get Returns the newest record of the user key with a sequence number up to seq.
+//
+// A deleted key is reported with kind KindDelete and `true`, `false` means the memtable
+// knows nothing about the key. A seq above MaxSeq reads like MaxSeq.
+func (m *Memtable) Get(key []byte, seq uint64) ([]byte, Kind, bool) {
+	// The trailer has no room for larger sequence numbers, they would wrap around.
+	seq = min(seq, MaxSeq)
+	n := m.next(m.findLess(InternalKey{UserKey: key, Seq: seq, Kind: 0xff}), 0).Load()
+	if n == nullOffset {
+		return nil, 0, false
+	}
+	k := m.key(n)
+	if !bytes.Equal(k.UserKey, key) {
+		return nil, 0, false
+	}
+	return m.value(n), k.Kind, true
+}
+
+// Iter Returns an iterator over all records in internal key order.
+func (m *Memtable) Iter() *MemtableIter {
+	return &MemtableIter{m: m, curr: m.head}
+}
+
+// Seek Returns an iterator over the records from the newest one of the user key on.
+func (m *Memtable) Seek(key []byte) *MemtableIter {
+	return &MemtableIter{m: m, curr: m.findLess(InternalKey{UserKey: key, Seq: MaxSeq, Kind: 0xff})}
+}
+
+// MemtableIter An iterator over the records of a memtable, in internal key order.
+//
+// Records added while iterating may or may not be yielded.
+type MemtableIter struct {
+	m *Memtable
+	// the offset of the current node, the head before the first call to Next
+	curr uint32
+}
+
+// Next Advances to the next record and returns `false` once there are no more.
+func (it *MemtableIter) Next() bool {
+	if it.curr == nullOffset {
+		return false
+	}
+	it.curr = it.m.next(it.curr, 0).Load()
+	return it.curr != nullOffset
+}
+
+// Key Returns the internal key of the current record.
+func (it *MemtableIter) Key() InternalKey {
+	return it.m.key(it.curr)
+}
+
+// Value Returns the value of the current record.
+func (it *MemtableIter) Value() []byte {
+	return it.m.value(it.curr)
+}
+
+// newNode Allocates a node and fills in everything but its tower.
+func (m *Memtable) newNode(key InternalKey, value []byte, height int) (uint32, error) {
+	keySize := len(key.UserKey) + trailerSize
+	size := uint64(nodeTower) + 4*uint64(height) + uint64(keySize) + uint64(len(value))
+	if size > uint64(len(m.arena.buf)) {
+		return nullOffset, ErrArenaFull
+	}
+	n, err := m.arena.alloc(uint32(size), 4)
+	if err != nil {
+		return nullOffset, err
+	}
+	header := m.arena.bytes(n, nodeTower)
+	binary.LittleEndian.PutUint32(header[nodeKeySize:], uint32(keySize))
+	binary.LittleEndian.PutUint32(header[nodeValueSize:], uint32(len(value)))
+	binary.LittleEndian.PutUint32(header[nodeHeight:], uint32(height))
+	data := m.arena.bytes(n+nodeTower+4*uint32(height), uint32(keySize+len(value)))
+	copy(data, key.UserKey)
+	binary.LittleEndian.PutUint64(data[len(key.UserKey):], key.trailer())
+	copy(data[keySize:], value)
+	return n, nil
+}
+
+// next Returns the tower slot of node n at level.
+func (m *Memtable) next(n uint32, level int) *atomic.Uint32 {
+	return m.arena.uint32At(n + nodeTower + 4*uint32(level))
+}
+
+func (m *Memtable) header(n uint32, field uint32) uint32 {
+	return binary.LittleEndian.Uint32(m.arena.bytes(n+field, 4))
+}
+
+// data Returns the offset of the key of node n.
+func (m *Memtable) data(n uint32) uint32 {
+	return n + nodeTower + 4*m.header(n, nodeHeight)
+}
+
+func (m *Memtable) key(n uint32) InternalKey {
+	ikey := m.arena.bytes(m.data(n), m.header(n, nodeKeySize))
+	trailer := binary.LittleEndian.Uint64(ikey[len(ikey)-trailerSize:])
+	return InternalKey{UserKey: ikey[:len(ikey)-trailerSize], Seq: trailer >> 8, Kind: Kind(trailer)}
+}
+
+func (m *Memtable) value(n uint32) []byte {
+	return m.arena.bytes(m.data(n)+m.header(n, nodeKeySize), m.header(n, nodeValueSize))
+}
+
+// findSplice Walks level from pred and returns the last node before key and the first one
+// at or after it, and whether that one has key.
+func (m *Memtable) findSplice(key InternalKey, level int, pred uint32) (uint32, uint32, bool) {
+	for {
+		next := m.next(pred, level).Load()
+		if next == nullOffset {
+			return pred, next, false
+		}
+		switch c := CompareInternalKeys(key, m.key(next)); {
+		case c == 0:
+			return pred, next, true
+		case c < 0:
+			return pred, next, false
+		}
+		pred = next
+	}
+}
+
+// findLess Returns the last node before key, the head if there is none.
+func (m *Memtable) findLess(key InternalKey) uint32 {
+	pred := m.head
+	for level := int(m.height.Load()) - 1; level >= 0; level-- {
+		pred, _, _ = m.findSplice(key, level, pred)
+	}
+	return pred
+}
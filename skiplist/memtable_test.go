@@ -0,0 +1,125 @@
+package skiplist
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemtable(t *testing.T) {
+	m := NewMemtable(1<<16, WithSeed(1))
+	empty := m.ApproximateMemoryUsage()
+	_, _, ok := m.Get([]byte("a"), MaxSeq)
+	assert.False(t, ok)
+
+	assert.NoError(t, m.Add(InternalKey{[]byte("b"), 1, KindSet}, []byte("b1")))
+	assert.NoError(t, m.Add(InternalKey{[]byte("a"), 2, KindSet}, []byte("a2")))
+	assert.NoError(t, m.Add(InternalKey{[]byte("b"), 3, KindDelete}, nil))
+	assert.NoError(t, m.Add(InternalKey{[]byte("b"), 4, KindSet}, []byte("b4")))
+	assert.NoError(t, m.Add(InternalKey{[]byte(""), 5, KindSet}, []byte("empty")))
+	assert.ErrorIs(t, m.Add(InternalKey{[]byte("b"), 3, KindDelete}, []byte("again")), ErrRecordExists)
+	assert.Error(t, m.Add(InternalKey{[]byte("c"), MaxSeq + 1, KindSet}, nil))
+	assert.EqualValues(t, 5, m.Len())
+	assert.Greater(t, m.ApproximateMemoryUsage(), empty)
+
+	for _, tc := range []struct {
+		key   string
+		seq   uint64
+		value string
+		kind  Kind
+		ok    bool
+	}{
+		{"b", MaxSeq, "b4", KindSet, true},
+		{"b", MaxSeq + 1, "b4", KindSet, true},
+		{"b", 1<<64 - 1, "b4", KindSet, true},
+		{"b", 4, "b4", KindSet, true},
+		{"b", 3, "", KindDelete, true},
+		{"b", 2, "b1", KindSet, true},
+		{"b", 0, "", 0, false},
+		{"a", 1, "", 0, false},
+		{"a", 2, "a2", KindSet, true},
+		{"", 9, "empty", KindSet, true},
+		{"c", MaxSeq, "", 0, false},
+		{"ab", MaxSeq, "", 0, false},
+	} {
+		v, kind, ok := m.Get([]byte(tc.key), tc.seq)
+		assert.Equal(t, tc.ok, ok, "%q#%d", tc.key, tc.seq)
+		assert.Equal(t, tc.kind, kind, "%q#%d", tc.key, tc.seq)
+		assert.Equal(t, tc.value, string(v), "%q#%d", tc.key, tc.seq)
+	}
+
+	var got []string
+	for it := m.Iter(); it.Next(); {
+		got = append(got, it.Key().String()+"="+string(it.Value()))
+	}
+	assert.Equal(t, []string{`""#5,1=empty`, `"a"#2,1=a2`, `"b"#4,1=b4`, `"b"#3,0=`, `"b"#1,1=b1`}, got)
+
+	it := m.Seek([]byte("aa"))
+	assert.True(t, it.Next())
+	assert.Equal(t, InternalKey{[]byte("b"), 4, KindSet}, it.Key())
+	it = m.Seek([]byte("c"))
+	assert.False(t, it.Next())
+	assert.False(t, it.Next())
+}
+
+func TestMemtableFull(t *testing.T) {
+	m := NewMemtable(4096, WithMaxHeight(4))
+	assert.ErrorIs(t, m.Add(InternalKey{UserKey: make([]byte, 5000), Seq: 1}, nil), ErrArenaFull)
+	var err error
+	var added uint64
+	for i := 0; err == nil; i++ {
+		if err = m.Add(InternalKey{[]byte(fmt.Sprint(i)), uint64(i), KindSet}, []byte("value")); err == nil {
+			added++
+		}
+	}
+	assert.ErrorIs(t, err, ErrArenaFull)
+	assert.Equal(t, added, m.Len())
+	assert.LessOrEqual(t, m.ApproximateMemoryUsage(), uint64(4096))
+	// what was added before the arena filled up is still there
+	for i := uint64(0); i < added; i++ {
+		v, _, ok := m.Get([]byte(fmt.Sprint(i)), MaxSeq)
+		assert.True(t, ok)
+		assert.Equal(t, "value", string(v))
+	}
+	assert.Panics(t, func() { NewMemtable(16) })
+}
+
+func TestMemtableConcurrent(t *testing.T) {
+	const workers, perWorker = 4, 2000
+	m := NewMemtable(1 << 22)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				// every worker writes every key, with its own sequence numbers
+				key := []byte(fmt.Sprintf("key%04d", i))
+				seq := uint64(i*workers + w)
+				assert.NoError(t, m.Add(InternalKey{key, seq, KindSet}, key))
+				// and some duplicates race with each other
+				if err := m.Add(InternalKey{key, uint64(i), KindDelete}, nil); err != nil {
+					assert.ErrorIs(t, err, ErrRecordExists)
+				}
+				v, _, ok := m.Get(key, seq)
+				assert.True(t, ok)
+				assert.Equal(t, key, v)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	assert.EqualValues(t, workers*perWorker+perWorker, m.Len())
+	var prev *InternalKey
+	n := 0
+	for it := m.Iter(); it.Next(); n++ {
+		k := it.Key()
+		if prev != nil {
+			assert.Negative(t, CompareInternalKeys(*prev, k), "%v before %v", prev, k)
+		}
+		prev = &k
+	}
+	assert.EqualValues(t, m.Len(), n)
+}
@@ -0,0 +1,165 @@
+package skiplist
+
+import (
+	"cmp"
+	"container/heap"
+
+	"github.com/crrow/reona/util"
+)
+
+// DuplicatePolicy Tells a MergeIter what to do with a key yielded by several sources.
+type DuplicatePolicy uint8
+
+const (
+	// NewestWins Yields a key once, with the value of the first source that has it.
+	NewestWins DuplicatePolicy = iota
+	// YieldAll Yields a key once per source that has it, the first source first.
+	YieldAll
+)
+
+// MergeConfig Configures a MergeIter, see WithDuplicates and WithReverse.
+type MergeConfig struct {
+	duplicates DuplicatePolicy
+	reverse    bool
+}
+
+// WithDuplicates Sets how keys present in several sources are yielded, NewestWins by
+// default.
+func WithDuplicates(policy DuplicatePolicy) util.Option[MergeConfig] {
+	return util.OptionFunc[MergeConfig](func(c *MergeConfig) {
+		c.duplicates = policy
+	})
+}
+
+// WithReverse Merges sources that yield keys in descending order, like the iterators of
+// SkipList.Descend, into one descending sequence.
+func WithReverse() util.Option[MergeConfig] {
+	return util.OptionFunc[MergeConfig](func(c *MergeConfig) {
+		c.reverse = true
+	})
+}
+
+// MergeIter An iterator yielding the entries of several sorted sources as one sorted
+// sequence.
+//
+// Sources are ordered from newest to oldest: when several of them have a key, the first
+// one wins, as with an active skip list in front of frozen ones. Each step costs
+// O(log k) comparisons for k sources.
+type MergeIter[K any, V any] struct {
+	config MergeConfig
+	heap   mergeHeap[K, V]
+	// the sources that still have to yield their first entry, nil once started
+	sources []Iterator[K, V]
+	// the source of the current entry, advanced by the next call to Next
+	curr *mergeSource[K, V]
+}
+
+// Merge Returns an iterator over the entries of sources, ordered by the natural order of
+// the keys.
+func Merge[K cmp.Ordered, V any](sources []Iterator[K, V], opts ...util.Option[MergeConfig]) *MergeIter[K, V] {
+	return MergeFunc[K, V](cmp.Compare[K], sources, opts...)
+}
+
+// MergeFunc Returns an iterator over the entries of sources, ordered by compare.
+func MergeFunc[K any, V any](compare func(a, b K) int, sources []Iterator[K, V], opts ...util.Option[MergeConfig]) *MergeIter[K, V] {
+	it := &MergeIter[K, V]{sources: sources}
+	util.ApplyOptions(&it.config, opts...)
+	it.heap.compare = compare
+	if it.config.reverse {
+		it.heap.compare = func(a, b K) int { return compare(b, a) }
+	}
+	return it
+}
+
+// Next Advances to the next entry and returns `false` once all sources are exhausted.
+func (it *MergeIter[K, V]) Next() bool {
+	if it.sources != nil {
+		for i, src := range it.sources {
+			s := &mergeSource[K, V]{it: src, index: i}
+			if s.next() {
+				it.heap.sources = append(it.heap.sources, s)
+			}
+		}
+		it.sources = nil
+		heap.Init(&it.heap)
+	} else if it.curr != nil && it.curr.next() {
+		heap.Push(&it.heap, it.curr)
+	}
+
+	if it.heap.Len() == 0 {
+		it.curr = nil
+		return false
+	}
+	it.curr = heap.Pop(&it.heap).(*mergeSource[K, V])
+	if it.config.duplicates == NewestWins {
+		// Older sources with the same key are next in the heap, skip their entries.
+		for it.heap.Len() > 0 && it.heap.compare(it.heap.sources[0].key, it.curr.key) == 0 {
+			if s := it.heap.sources[0]; s.next() {
+				heap.Fix(&it.heap, 0)
+			} else {
+				heap.Pop(&it.heap)
+			}
+		}
+	}
+	return true
+}
+
+// Key Returns the key of the current entry.
+func (it *MergeIter[K, V]) Key() K {
+	return it.curr.key
+}
+
+// Value Returns the value of the current entry.
+func (it *MergeIter[K, V]) Value() V {
+	return it.curr.it.Value()
+}
+
+// Source Returns the index of the source of the current entry.
+func (it *MergeIter[K, V]) Source() int {
+	return it.curr.index
+}
+
+// mergeSource A source together with its current key.
+type mergeSource[K any, V any] struct {
+	it    Iterator[K, V]
+	key   K
+	index int
+}
+
+func (s *mergeSource[K, V]) next() bool {
+	if !s.it.Next() {
+		return false
+	}
+	s.key = s.it.Key()
+	return true
+}
+
+// mergeHeap A heap.Interface of sources, the one with the smallest key on top, ties broken
+// by the order of the sources.
+type mergeHeap[K any, V any] struct {
+	sources []*mergeSource[K, V]
+	compare func(a, b K) int
+}
+
+func (h *mergeHeap[K, V]) Len() int { return len(h.sources) }
+
+func (h *mergeHeap[K, V]) Less(i, j int) bool {
+	if c := h.compare(h.sources[i].key, h.sources[j].key); c != 0 {
+		return c < 0
+	}
+	return h.sources[i].index < h.sources[j].index
+}
+
+func (h *mergeHeap[K, V]) Swap(i, j int) {
+	h.sources[i], h.sources[j] = h.sources[j], h.sources[i]
+}
+
+func (h *mergeHeap[K, V]) Push(x any) {
+	h.sources = append(h.sources, x.(*mergeSource[K, V]))
+}
+
+func (h *mergeHeap[K, V]) Pop() any {
+	last := h.sources[len(h.sources)-1]
+	h.sources = h.sources[:len(h.sources)-1]
+	return last
+}
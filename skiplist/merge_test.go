@@ -0,0 +1,83 @@
+package skiplist
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mergeLists Returns three skip lists, the first one the newest: every list has the keys
+// divisible by its step, with values naming the list.
+func mergeLists() []*SkipList[int, string] {
+	var lists []*SkipList[int, string]
+	for i, step := range []int{2, 3, 5} {
+		list := New[int, string]()
+		for k := 0; k <= 15; k += step {
+			list.Insert(k, fmt.Sprint(i))
+		}
+		lists = append(lists, list)
+	}
+	return lists
+}
+
+func drain(t *testing.T, it *MergeIter[int, string]) []string {
+	var r []string
+	for it.Next() {
+		// every value names its source
+		assert.Equal(t, fmt.Sprint(it.Source()), it.Value())
+		r = append(r, fmt.Sprintf("%d:%s", it.Key(), it.Value()))
+	}
+	return r
+}
+
+func TestMerge(t *testing.T) {
+	lists := mergeLists()
+	ascending := func() []Iterator[int, string] {
+		var r []Iterator[int, string]
+		for _, list := range lists {
+			r = append(r, list.Range(Unbounded[int](), Unbounded[int]()))
+		}
+		return r
+	}
+	descending := func() []Iterator[int, string] {
+		var r []Iterator[int, string]
+		for _, list := range lists {
+			r = append(r, list.Descend(Unbounded[int]()))
+		}
+		return r
+	}
+
+	assert.Equal(t, []string{"0:0", "2:0", "3:1", "4:0", "5:2", "6:0", "8:0", "9:1", "10:0", "12:0", "14:0", "15:1"},
+		drain(t, Merge(ascending())))
+	assert.Equal(t, []string{"0:0", "0:1", "0:2", "2:0", "3:1", "4:0", "5:2", "6:0", "6:1", "8:0", "9:1", "10:0", "10:2", "12:0", "12:1", "14:0", "15:1", "15:2"},
+		drain(t, Merge(ascending(), WithDuplicates(YieldAll))))
+	assert.Equal(t, []string{"15:1", "14:0", "12:0", "10:0", "9:1", "8:0", "6:0", "5:2", "4:0", "3:1", "2:0", "0:0"},
+		drain(t, Merge(descending(), WithReverse())))
+	assert.Equal(t, []string{"15:1", "15:2", "14:0", "12:0", "12:1", "10:0", "10:2", "9:1", "8:0", "6:0", "6:1", "5:2", "4:0", "3:1", "2:0", "0:0", "0:1", "0:2"},
+		drain(t, Merge(descending(), WithReverse(), WithDuplicates(YieldAll))))
+
+	// the oldest source wins if it comes first
+	reversed := ascending()
+	reversed[0], reversed[2] = reversed[2], reversed[0]
+	it := Merge(reversed)
+	assert.True(t, it.Next())
+	assert.Equal(t, 0, it.Key())
+	assert.Equal(t, "2", it.Value())
+	assert.Equal(t, 0, it.Source())
+
+	assert.Nil(t, drain(t, Merge[int, string](nil)))
+	empty := New[int, string]()
+	it = Merge([]Iterator[int, string]{empty.Range(Unbounded[int](), Unbounded[int]()), lists[1].Range(Included(10), Unbounded[int]())})
+	assert.Equal(t, []string{"12:1", "15:1"}, drain(t, it))
+	assert.False(t, it.Next())
+
+	// merged iterators compose
+	inner := Merge([]Iterator[int, string]{lists[1].Range(Unbounded[int](), Unbounded[int]())})
+	outer := Merge([]Iterator[int, string]{inner, lists[2].Range(Unbounded[int](), Unbounded[int]())})
+	var ks []int
+	for outer.Next() {
+		ks = append(ks, outer.Key())
+	}
+	assert.Equal(t, []int{0, 3, 5, 6, 9, 10, 12, 15}, ks)
+}
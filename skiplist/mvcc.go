@@ -0,0 +1,247 @@
+package skiplist
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/crrow/reona/util"
+)
+
+// Versioned A skip list that keeps old versions of its entries for as long as snapshots
+// need them (multi-version concurrency control).
+//
+// Every write gets the next sequence number, and a Snapshot taken at some sequence number
+// sees every entry as it was then, while writers carry on. Versions that no snapshot can
+// see any more are unlinked as soon as the next write to the key or the release of a
+// snapshot notices it.
+//
+// Writers and the creation of snapshots are serialized by a mutex, readers never block.
+//
+// The empty value is not usable, use NewVersioned or NewVersionedFunc.
+type Versioned[K any, V any] struct {
+	mu sync.Mutex
+	sl *SkipList[K, *record[K, V]]
+	// the sequence number of the last write, guarded by mu
+	seq uint64
+	// the sequence numbers of the live snapshots in ascending order, guarded by mu
+	snapshots []uint64
+	// the records keeping versions for snapshots, guarded by mu
+	dirty []*record[K, V]
+}
+
+// record The versions of one key.
+type record[K any, V any] struct {
+	key K
+	// the newest version, a tombstone if the key was removed
+	head atomic.Pointer[version[V]]
+	// whether the record is in Versioned.dirty, guarded by Versioned.mu
+	dirty bool
+}
+
+type version[V any] struct {
+	seq     uint64
+	value   V
+	deleted bool
+	// the next older version, versions are only ever unlinked
+	older atomic.Pointer[version[V]]
+}
+
+// NewVersioned Returns an empty versioned skip list ordered by the natural order of the
+// keys, see Config for the options.
+func NewVersioned[K cmp.Ordered, V any](opts ...util.Option[Config]) *Versioned[K, V] {
+	return NewVersionedFunc[K, V](cmp.Compare[K], opts...)
+}
+
+// NewVersionedFunc Returns an empty versioned skip list ordered by compare, see NewFunc.
+func NewVersionedFunc[K any, V any](compare func(a, b K) int, opts ...util.Option[Config]) *Versioned[K, V] {
+	return &Versioned[K, V]{sl: NewFunc[K, *record[K, V]](compare, opts...)}
+}
+
+// Insert Sets the value of the key and returns the sequence number of the write.
+func (v *Versioned[K, V]) Insert(key K, value V) uint64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.write(key, &version[V]{value: value})
+}
+
+// Remove Removes the key, returns `false` if there was none.
+func (v *Versioned[K, V]) Remove(key K) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	r, ok := v.sl.Get(key)
+	if !ok || r.head.Load().deleted {
+		return false
+	}
+	v.write(key, &version[V]{deleted: true})
+	return true
+}
+
+// Get Returns the current value of the key, if it exists.
+func (v *Versioned[K, V]) Get(key K) (V, bool) {
+	return v.get(key, ^uint64(0))
+}
+
+// Snapshot Returns a read-only view of the skip list as of now.
+//
+// The snapshot keeps the versions it sees alive until it is released.
+func (v *Versioned[K, V]) Snapshot() *Snapshot[K, V] {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	// the sequence numbers only grow, so appending keeps them sorted
+	v.snapshots = append(v.snapshots, v.seq)
+	return &Snapshot[K, V]{v: v, seq: v.seq}
+}
+
+// write Links ver as the newest version of the key, v.mu must be held.
+func (v *Versioned[K, V]) write(key K, ver *version[V]) uint64 {
+	v.seq++
+	ver.seq = v.seq
+	r, ok := v.sl.Get(key)
+	if !ok {
+		r = &record[K, V]{key: key}
+		r.head.Store(ver)
+		v.sl.Insert(key, r)
+		return ver.seq
+	}
+	ver.older.Store(r.head.Load())
+	r.head.Store(ver)
+	v.prune(r)
+	return ver.seq
+}
+
+// prune Unlinks the versions of r that no snapshot sees, v.mu must be held.
+//
+// Readers still walking through an unlinked version keep following its older pointer, and
+// only readers of released snapshots would have stopped there.
+func (v *Versioned[K, V]) prune(r *record[K, V]) {
+	// the newest version is seen by everyone from now on
+	keep := r.head.Load()
+	newer := keep.seq
+	for ver := keep.older.Load(); ver != nil; ver = ver.older.Load() {
+		// ver is what a snapshot between its own and the next newer version sees
+		i, _ := slices.BinarySearch(v.snapshots, ver.seq)
+		if i < len(v.snapshots) && v.snapshots[i] < newer {
+			if keep.older.Load() != ver {
+				keep.older.Store(ver)
+			}
+			keep = ver
+		}
+		newer = ver.seq
+	}
+	if keep.older.Load() != nil {
+		keep.older.Store(nil)
+	}
+	// Tombstones at the old end hide nothing, cut them off too.
+	head := r.head.Load()
+	last := head
+	for ver := head; ver != nil; ver = ver.older.Load() {
+		if !ver.deleted {
+			last = ver
+		}
+	}
+	if last.older.Load() != nil {
+		last.older.Store(nil)
+	}
+
+	switch {
+	case head.deleted && head.older.Load() == nil:
+		// Nobody sees the key any more.
+		v.sl.Remove(r.key)
+	case head.older.Load() != nil && !r.dirty:
+		r.dirty = true
+		v.dirty = append(v.dirty, r)
+	}
+}
+
+func (v *Versioned[K, V]) get(key K, seq uint64) (V, bool) {
+	if r, ok := v.sl.Get(key); ok {
+		if ver := r.visible(seq); ver != nil {
+			return ver.value, true
+		}
+	}
+	var zero V
+	return zero, false
+}
+
+// visible Returns the newest version up to seq, nil if there is none or it is a tombstone.
+func (r *record[K, V]) visible(seq uint64) *version[V] {
+	ver := r.head.Load()
+	for ver != nil && ver.seq > seq {
+		ver = ver.older.Load()
+	}
+	if ver == nil || ver.deleted {
+		return nil
+	}
+	return ver
+}
+
+// Snapshot A read-only view of a Versioned skip list at a sequence number.
+type Snapshot[K any, V any] struct {
+	v        *Versioned[K, V]
+	seq      uint64
+	released bool
+}
+
+// Seq Returns the sequence number of the last write the snapshot sees.
+func (s *Snapshot[K, V]) Seq() uint64 {
+	return s.seq
+}
+
+// Get Returns the value the key had at the snapshot, if it existed.
+func (s *Snapshot[K, V]) Get(key K) (V, bool) {
+	return s.v.get(key, s.seq)
+}
+
+// Range Returns an iterator over the entries of the snapshot with keys between lo and hi.
+func (s *Snapshot[K, V]) Range(lo, hi Bound[K]) *SnapshotIter[K, V] {
+	return &SnapshotIter[K, V]{it: s.v.sl.Range(lo, hi), seq: s.seq}
+}
+
+// Release Releases the snapshot, the versions only it sees can be collected.
+func (s *Snapshot[K, V]) Release() {
+	v := s.v
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if s.released {
+		return
+	}
+	s.released = true
+	i, _ := slices.BinarySearch(v.snapshots, s.seq)
+	v.snapshots = slices.Delete(v.snapshots, i, i+1)
+
+	dirty := v.dirty
+	v.dirty = nil
+	for _, r := range dirty {
+		r.dirty = false
+		v.prune(r)
+	}
+}
+
+// SnapshotIter An iterator over the entries of a snapshot in ascending key order.
+type SnapshotIter[K any, V any] struct {
+	it  *Iter[K, *record[K, V]]
+	seq uint64
+	ver *version[V]
+}
+
+// Next Advances to the next entry and returns `false` once the range is exhausted.
+func (it *SnapshotIter[K, V]) Next() bool {
+	for it.it.Next() {
+		if it.ver = it.it.Value().visible(it.seq); it.ver != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Key Returns the key of the current entry.
+func (it *SnapshotIter[K, V]) Key() K {
+	return it.it.Key()
+}
+
+// Value Returns the value of the current entry.
+func (it *SnapshotIter[K, V]) Value() V {
+	return it.ver.value
+}
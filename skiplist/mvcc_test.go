@@ -0,0 +1,159 @@
+package skiplist
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// versions Returns the number of versions kept for the key.
+func versions[K any, V any](v *Versioned[K, V], key K) int {
+	r, ok := v.sl.Get(key)
+	if !ok {
+		return 0
+	}
+	n := 0
+	for ver := r.head.Load(); ver != nil; ver = ver.older.Load() {
+		n++
+	}
+	return n
+}
+
+func collectSnapshot[K int, V any](it *SnapshotIter[K, V]) map[K]V {
+	r := map[K]V{}
+	for it.Next() {
+		r[it.Key()] = it.Value()
+	}
+	return r
+}
+
+func TestVersioned(t *testing.T) {
+	v := NewVersioned[int, string]()
+	v.Insert(1, "a")
+	v.Insert(2, "b")
+	s1 := v.Snapshot()
+	assert.EqualValues(t, 2, s1.Seq())
+
+	v.Insert(1, "a'")
+	assert.True(t, v.Remove(2))
+	assert.False(t, v.Remove(2))
+	assert.False(t, v.Remove(5))
+	v.Insert(3, "c")
+	s2 := v.Snapshot()
+	v.Insert(3, "c'")
+
+	got, ok := v.Get(1)
+	assert.True(t, ok)
+	assert.Equal(t, "a'", got)
+	_, ok = v.Get(2)
+	assert.False(t, ok)
+
+	got, ok = s1.Get(1)
+	assert.True(t, ok)
+	assert.Equal(t, "a", got)
+	got, ok = s1.Get(2)
+	assert.True(t, ok)
+	assert.Equal(t, "b", got)
+	_, ok = s1.Get(3)
+	assert.False(t, ok)
+
+	all := Unbounded[int]()
+	assert.Equal(t, map[int]string{1: "a", 2: "b"}, collectSnapshot(s1.Range(all, all)))
+	assert.Equal(t, map[int]string{1: "a'", 3: "c"}, collectSnapshot(s2.Range(all, all)))
+	assert.Equal(t, map[int]string{3: "c"}, collectSnapshot(s2.Range(Included(2), all)))
+
+	assert.Equal(t, 2, versions(v, 1))
+	assert.Equal(t, 2, versions(v, 2))
+	assert.Equal(t, 2, versions(v, 3))
+
+	// s2 still sees 1 and 3 as they were, but nobody sees "b" any more
+	s1.Release()
+	s1.Release()
+	assert.Equal(t, 1, versions(v, 1))
+	assert.Equal(t, 0, versions(v, 2))
+	assert.Equal(t, 2, versions(v, 3))
+	got, _ = s2.Get(3)
+	assert.Equal(t, "c", got)
+
+	s2.Release()
+	assert.Equal(t, 1, versions(v, 3))
+	assert.Empty(t, v.snapshots)
+	assert.Empty(t, v.dirty)
+
+	// without snapshots only the newest version is kept
+	for i := 0; i < 10; i++ {
+		v.Insert(4, "d")
+	}
+	assert.Equal(t, 1, versions(v, 4))
+	v.Remove(4)
+	assert.Equal(t, 0, versions(v, 4))
+}
+
+func TestVersionedPrunesBetweenSnapshots(t *testing.T) {
+	v := NewVersioned[int, int]()
+	v.Insert(0, 1)
+	s1 := v.Snapshot()
+	for i := 2; i <= 5; i++ {
+		v.Insert(0, i)
+	}
+	s2 := v.Snapshot()
+	v.Insert(0, 6)
+	// 1 for s1, 5 for s2 and the current 6
+	assert.Equal(t, 3, versions(v, 0))
+	got, _ := s1.Get(0)
+	assert.Equal(t, 1, got)
+	got, _ = s2.Get(0)
+	assert.Equal(t, 5, got)
+
+	// a tombstone followed by a removed key only hides nothing
+	v.Remove(0)
+	s2.Release()
+	v.Insert(0, 7)
+	s1.Release()
+	assert.Equal(t, 1, versions(v, 0))
+}
+
+func TestVersionedConcurrent(t *testing.T) {
+	const keys = 64
+	v := NewVersioned[int, int]()
+	// every write sets all keys to the same value, so a consistent view has one value
+	for k := 0; k < keys; k++ {
+		v.Insert(k, 0)
+	}
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for round := 1; !stop.Load(); round++ {
+			for k := 0; k < keys; k++ {
+				v.Insert(k, round)
+			}
+			runtime.Gosched()
+		}
+	}()
+
+	for round := 0; round < 50; round++ {
+		s := v.Snapshot()
+		all := collectSnapshot(s.Range(Unbounded[int](), Unbounded[int]()))
+		assert.Len(t, all, keys)
+		// the snapshot falls between two writes of some round: keys before that point have
+		// the new value, the rest the old one
+		for k := 1; k < keys; k++ {
+			assert.LessOrEqual(t, all[k], all[k-1])
+			assert.LessOrEqual(t, all[0]-all[k], 1)
+		}
+		runtime.Gosched()
+		// the snapshot does not notice the writes that followed
+		assert.Equal(t, all, collectSnapshot(s.Range(Unbounded[int](), Unbounded[int]())))
+		s.Release()
+	}
+	stop.Store(true)
+	wg.Wait()
+	for k := 0; k < keys; k++ {
+		assert.Equal(t, 1, versions(v, k))
+	}
+}
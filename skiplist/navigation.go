@@ -0,0 +1,79 @@
+package skiplist
+
+// Front Returns the entry with the smallest key.
+func (sl *SkipList[K, V]) Front() (*Node[K, V], bool) {
+	n := sl.nextNode(&sl.head, Unbounded[K]())
+	return n, n != nil
+}
+
+// Back Returns the entry with the largest key.
+func (sl *SkipList[K, V]) Back() (*Node[K, V], bool) {
+	n := sl.searchBound(Unbounded[K](), true)
+	return n, n != nil
+}
+
+// Floor Returns the entry with the greatest key less than or equal to key.
+func (sl *SkipList[K, V]) Floor(key K) (*Node[K, V], bool) {
+	n := sl.searchBound(Included(key), true)
+	return n, n != nil
+}
+
+// Ceiling Returns the entry with the least key greater than or equal to key.
+func (sl *SkipList[K, V]) Ceiling(key K) (*Node[K, V], bool) {
+	n := sl.searchBound(Included(key), false)
+	return n, n != nil
+}
+
+// Lower Returns the entry with the greatest key strictly less than key.
+func (sl *SkipList[K, V]) Lower(key K) (*Node[K, V], bool) {
+	n := sl.searchBound(Excluded(key), true)
+	return n, n != nil
+}
+
+// Higher Returns the entry with the least key strictly greater than key.
+func (sl *SkipList[K, V]) Higher(key K) (*Node[K, V], bool) {
+	n := sl.searchBound(Excluded(key), false)
+	return n, n != nil
+}
+
+// PopFront Removes the entry with the smallest key and returns it.
+//
+// If several goroutines pop concurrently, every entry is returned to exactly one of them.
+func (sl *SkipList[K, V]) PopFront() (*Node[K, V], bool) {
+	for {
+		n, ok := sl.Front()
+		if !ok {
+			return nil, false
+		}
+		if sl.removeNode(n) {
+			return n, true
+		}
+	}
+}
+
+// PopBack Removes the entry with the largest key and returns it.
+//
+// If several goroutines pop concurrently, every entry is returned to exactly one of them.
+func (sl *SkipList[K, V]) PopBack() (*Node[K, V], bool) {
+	for {
+		n, ok := sl.Back()
+		if !ok {
+			return nil, false
+		}
+		if sl.removeNode(n) {
+			return n, true
+		}
+	}
+}
+
+// removeNode Removes n from the skip list, returns `false` if someone else removed it first.
+func (sl *SkipList[K, V]) removeNode(n *Node[K, V]) bool {
+	// Try removing the node by marking its tower.
+	if !n.markTower() {
+		return false
+	}
+	sl.len.Add(^uint64(0))
+	// Search for the key to unlink the node.
+	sl.searchBound(Included(n.key), false)
+	return true
+}
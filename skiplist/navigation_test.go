@@ -0,0 +1,109 @@
+package skiplist
+
+import (
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNavigation(t *testing.T) {
+	list := New[int, string]()
+	for _, f := range []func(int) (*Node[int, string], bool){list.Floor, list.Ceiling, list.Lower, list.Higher} {
+		_, ok := f(1)
+		assert.False(t, ok)
+	}
+	_, ok := list.Back()
+	assert.False(t, ok)
+
+	for _, k := range []int{10, 20, 30} {
+		list.Insert(k, "")
+	}
+	key := func(n *Node[int, string], ok bool) any {
+		if !ok {
+			return nil
+		}
+		return n.Key()
+	}
+
+	assert.Equal(t, 10, key(list.Front()))
+	assert.Equal(t, 30, key(list.Back()))
+	for _, tc := range []struct {
+		key                           int
+		floor, ceiling, lower, higher any
+	}{
+		{5, nil, 10, nil, 10},
+		{10, 10, 10, nil, 20},
+		{15, 10, 20, 10, 20},
+		{20, 20, 20, 10, 30},
+		{30, 30, 30, 20, nil},
+		{35, 30, nil, 30, nil},
+	} {
+		assert.Equal(t, tc.floor, key(list.Floor(tc.key)), "Floor(%d)", tc.key)
+		assert.Equal(t, tc.ceiling, key(list.Ceiling(tc.key)), "Ceiling(%d)", tc.key)
+		assert.Equal(t, tc.lower, key(list.Lower(tc.key)), "Lower(%d)", tc.key)
+		assert.Equal(t, tc.higher, key(list.Higher(tc.key)), "Higher(%d)", tc.key)
+	}
+
+	assert.Equal(t, 10, key(list.PopFront()))
+	assert.Equal(t, 30, key(list.PopBack()))
+	assert.EqualValues(t, 1, list.Len())
+	assert.Equal(t, []int{20}, keys(list))
+	assert.Equal(t, 20, key(list.PopBack()))
+	assert.Nil(t, key(list.PopFront()))
+	assert.Nil(t, key(list.PopBack()))
+	assert.True(t, list.IsEmpty())
+}
+
+func TestConcurrentPop(t *testing.T) {
+	const n = 5000
+	list := New[int, int]()
+	for i := 0; i < n; i++ {
+		list.Insert(i, i)
+	}
+
+	var mtx sync.Mutex
+	var popped []int
+	var wg sync.WaitGroup
+	wg.Add(4)
+	for w := 0; w < 4; w++ {
+		go func(front bool) {
+			defer wg.Done()
+			var mine []int
+			prev := -1
+			if !front {
+				prev = n
+			}
+			for {
+				pop := list.PopBack
+				if front {
+					pop = list.PopFront
+				}
+				e, ok := pop()
+				if !ok {
+					break
+				}
+				// every popper sees the keys in order from its end
+				if front {
+					assert.Greater(t, e.Key(), prev)
+				} else {
+					assert.Less(t, e.Key(), prev)
+				}
+				prev = e.Key()
+				mine = append(mine, e.Key())
+			}
+			mtx.Lock()
+			popped = append(popped, mine...)
+			mtx.Unlock()
+		}(w%2 == 0)
+	}
+	wg.Wait()
+
+	sort.Ints(popped)
+	assert.Len(t, popped, n)
+	for i, k := range popped {
+		assert.Equal(t, i, k)
+	}
+	assert.True(t, list.IsEmpty())
+}
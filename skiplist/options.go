@@ -0,0 +1,149 @@
+package skiplist
+
+import (
+	"math"
+	"math/bits"
+	"math/rand"
+	"sync/atomic"
+
+	"github.com/crrow/reona/util"
+)
+
+// Config Tunes the towers of a skip list, see WithMaxHeight, WithProbability and WithSeed.
+type Config struct {
+	// the tallest tower a node may get, at most maxHeight
+	maxHeight int
+	// the probability that a tower reaching some level also reaches the next one
+	p float64
+	// log2(1/p) if p is a power of 1/2, which allows counting trailing zeros instead of
+	// taking logarithms, otherwise 0
+	shift int
+	// whether heights come from the seeded generator instead of the per-thread one
+	seeded bool
+	seed   uint64
+}
+
+func defaultConfig() Config {
+	return Config{maxHeight: maxHeight, p: 0.5, shift: 1}
+}
+
+func newConfig(opts ...util.Option[Config]) Config {
+	c := defaultConfig()
+	util.ApplyOptions(&c, opts...)
+	return c
+}
+
+// WithMaxHeight Limits towers to height levels, clamped to between 1 and 32.
+//
+// Lower towers use less memory, but searches get slower once the skip list holds more
+// than about (1/p)^height entries.
+func WithMaxHeight(height int) util.Option[Config] {
+	height = min(max(height, 1), maxHeight)
+	return util.OptionFunc[Config](func(c *Config) {
+		c.maxHeight = height
+	})
+}
+
+// WithProbability Sets the probability that a tower reaching one level also reaches the
+// next one, 1/2 by default.
+//
+// A tower is 1/(1-p) levels high on average, so 1/4 uses less memory than 1/2 and does a
+// few more comparisons per search. Powers of 1/2 are the cheapest to generate.
+//
+// p is clamped to between 0 and 1: with 0 every tower has a single level, like a linked
+// list, and with 1 every tower is as high as the max height.
+func WithProbability(p float64) util.Option[Config] {
+	if !(p > 0) {
+		p = 0
+	} else if p > 1 {
+		p = 1
+	}
+	shift := 0
+	if frac, exp := math.Frexp(p); frac == 0.5 {
+		shift = 1 - exp
+	}
+	return util.OptionFunc[Config](func(c *Config) {
+		c.p, c.shift = p, shift
+	})
+}
+
+// WithSeed Makes tower heights a deterministic function of the seed and the order of
+// insertions, which makes tests reproducible.
+//
+// Seeded heights come from one counter shared by all goroutines. Without a seed every
+// thread draws from its own generator and inserting goroutines never contend on it.
+func WithSeed(seed uint64) util.Option[Config] {
+	return util.OptionFunc[Config](func(c *Config) {
+		c.seeded, c.seed = true, seed
+	})
+}
+
+// Heights Draws random tower heights as the options of a Config ask for, for skip lists
+// outside this package such as skiplist/lazy.
+type Heights struct {
+	gen heightGen
+}
+
+// NewHeights Returns a generator of tower heights, see Config for the options.
+func NewHeights(opts ...util.Option[Config]) *Heights {
+	h := &Heights{}
+	h.gen.init(newConfig(opts...))
+	return h
+}
+
+// Max Returns the height of the tallest tower Next may return.
+func (h *Heights) Max() int {
+	return h.gen.maxHeight
+}
+
+// Next Returns a random height in [1, Max()].
+func (h *Heights) Next() int {
+	return h.gen.height()
+}
+
+// heightGen Generates tower heights for one skip list.
+type heightGen struct {
+	Config
+	// the state of the seeded generator
+	state atomic.Uint64
+}
+
+func (g *heightGen) init(c Config) {
+	g.Config = c
+	g.state.Store(c.seed)
+}
+
+// random Returns 64 random bits.
+func (g *heightGen) random() uint64 {
+	if !g.seeded {
+		// the top level functions of math/rand draw from a per-thread generator
+		// as long as nobody calls rand.Seed
+		return rand.Uint64()
+	}
+	// splitmix64: every call takes its own step of the counter, so concurrent callers
+	// never retry, and the output is a strong mix of the counter.
+	z := g.state.Add(0x9e3779b97f4a7c15)
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	return z ^ z>>31
+}
+
+// height Returns a random height in [1, maxHeight], every level is reached with
+// probability p from the level below.
+func (g *heightGen) height() int {
+	num := g.random()
+	var height int
+	switch {
+	case g.p <= 0:
+		return 1
+	case g.p >= 1:
+		return g.maxHeight
+	case g.shift != 0:
+		height = bits.TrailingZeros64(num)/g.shift + 1
+	default:
+		// inverse transform sampling of the geometric distribution, u is in (0, 1]
+		u := float64(num>>11+1) / (1 << 53)
+		height = int(math.Log(u)/math.Log(g.p)) + 1
+	}
+	return min(height, g.maxHeight)
+}
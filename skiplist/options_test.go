@@ -0,0 +1,86 @@
+package skiplist
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// heights Returns the tower heights of keys 0..n-1.
+func heights(list *SkipList[int, int], n int) []int {
+	r := make([]int, n)
+	for k := range r {
+		r[k] = list.searchBound(Included(k), false).Height()
+	}
+	return r
+}
+
+func TestWithSeed(t *testing.T) {
+	const n = 1000
+	build := func(seed uint64) *SkipList[int, int] {
+		list := New[int, int](WithSeed(seed))
+		for i := 0; i < n; i++ {
+			list.Insert(i, i)
+		}
+		return list
+	}
+
+	assert.Equal(t, heights(build(42), n), heights(build(42), n))
+	assert.NotEqual(t, heights(build(42), n), heights(build(43), n))
+}
+
+func TestWithMaxHeight(t *testing.T) {
+	const n = 5000
+	list := New[int, int](WithMaxHeight(3), WithSeed(1))
+	for i := n - 1; i >= 0; i-- {
+		list.Insert(i, i)
+	}
+	assert.Len(t, list.head.pointers, 3)
+	hs := heights(list, n)
+	assert.Contains(t, hs, 3)
+	for _, h := range hs {
+		assert.LessOrEqual(t, h, 3)
+	}
+	for i := 0; i < n; i += 3 {
+		assert.True(t, list.Remove(i))
+	}
+	checkTowers(t, list)
+	assert.EqualValues(t, n-(n+2)/3, list.Len())
+
+	assert.Equal(t, 1, newConfig(WithMaxHeight(0)).maxHeight)
+	assert.Equal(t, maxHeight, newConfig(WithMaxHeight(maxHeight+1)).maxHeight)
+}
+
+func TestWithProbability(t *testing.T) {
+	const n = 1 << 16
+	for _, p := range []float64{0.5, 0.25, 1.0 / 3, 0.125, 0.9} {
+		g := heightGen{}
+		g.init(newConfig(WithProbability(p), WithSeed(7)))
+		// every level is reached from the one below with probability p
+		var reached [maxHeight + 1]int
+		for i := 0; i < n; i++ {
+			for h := g.height(); h > 0; h-- {
+				reached[h]++
+			}
+		}
+		for h := 2; h <= 3; h++ {
+			assert.InDelta(t, p, float64(reached[h])/float64(reached[h-1]), 0.02, "p=%v level %d", p, h)
+		}
+	}
+
+	assert.Equal(t, 2, newConfig(WithProbability(0.25)).shift)
+	assert.Equal(t, 0, newConfig(WithProbability(0.3)).shift)
+
+	// out of range probabilities are clamped to the flattest and the tallest towers
+	for _, tt := range []struct {
+		p      float64
+		height int
+	}{{0, 1}, {-1, 1}, {math.NaN(), 1}, {1, 4}, {2, 4}} {
+		g := heightGen{}
+		g.init(newConfig(WithProbability(tt.p), WithMaxHeight(4)))
+		for i := 0; i < 100; i++ {
+			assert.Equal(t, tt.height, g.height(), "p=%v", tt.p)
+		}
+	}
+}
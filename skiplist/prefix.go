@@ -0,0 +1,47 @@
+package skiplist
+
+// Bytes Key types that PrefixScan can scan: strings and byte slices, both ordered
+// byte-wise.
+type Bytes interface {
+	~string | ~[]byte
+}
+
+// PrefixBounds Returns the bounds of the range of keys starting with prefix, in byte-wise
+// order.
+//
+// The upper bound is the least key greater than every key with the prefix: the prefix with
+// its last byte incremented, after dropping trailing 0xFF bytes that cannot be incremented.
+// If the prefix is empty or only 0xFF bytes, no such key exists and the range is unbounded
+// above. The upper bound never shares memory with prefix.
+func PrefixBounds[K Bytes](prefix K) (lo, hi Bound[K]) {
+	lo = Included(prefix)
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] != 0xff {
+			succ := make([]byte, i+1)
+			for j := range succ {
+				succ[j] = prefix[j]
+			}
+			succ[i]++
+			return lo, Excluded(K(succ))
+		}
+	}
+	return lo, Unbounded[K]()
+}
+
+// PrefixScan Returns an iterator over the entries of sl whose keys start with prefix.
+//
+// The skip list must be ordered byte-wise, like strings are by cmp.Compare and byte slices
+// by bytes.Compare, see NewFunc.
+func PrefixScan[K Bytes, V any](sl *SkipList[K, V], prefix K) *Iter[K, V] {
+	return sl.Range(PrefixBounds(prefix))
+}
+
+// PrefixCount Returns the number of entries of sl whose keys start with prefix, see
+// PrefixScan.
+func PrefixCount[K Bytes, V any](sl *SkipList[K, V], prefix K) int {
+	n := 0
+	for it := PrefixScan(sl, prefix); it.Next(); {
+		n++
+	}
+	return n
+}
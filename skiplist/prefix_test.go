@@ -0,0 +1,73 @@
+package skiplist
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrefixBounds(t *testing.T) {
+	for _, tt := range []struct {
+		prefix string
+		hi     Bound[string]
+	}{
+		{"user:123:", Excluded("user:123;")},
+		{"a\xff", Excluded("b")},
+		{"a\xfe\xff\xff", Excluded("a\xff")},
+		{"\xff\xff", Unbounded[string]()},
+		{"", Unbounded[string]()},
+	} {
+		lo, hi := PrefixBounds(tt.prefix)
+		assert.Equal(t, Included(tt.prefix), lo, tt.prefix)
+		assert.Equal(t, tt.hi, hi, tt.prefix)
+	}
+}
+
+func TestPrefixScan(t *testing.T) {
+	type key string
+	list := New[key, int]()
+	for i, k := range []key{"user:12", "user:123", "user:123:a", "user:123:b", "user:123;", "user:124:a", "\xff", "\xff\xff", "\xff\xff\x00"} {
+		list.Insert(k, i)
+	}
+	scan := func(prefix key) []key {
+		var r []key
+		for it := PrefixScan(list, prefix); it.Next(); {
+			r = append(r, it.Key())
+		}
+		return r
+	}
+
+	assert.Equal(t, []key{"user:123:a", "user:123:b"}, scan("user:123:"))
+	assert.Equal(t, []key{"user:123", "user:123:a", "user:123:b", "user:123;"}, scan("user:123"))
+	assert.Equal(t, []key{"\xff\xff", "\xff\xff\x00"}, scan("\xff\xff"))
+	assert.Empty(t, scan("users"))
+	assert.Equal(t, 9, PrefixCount(list, ""))
+	assert.Equal(t, 3, PrefixCount(list, "\xff"))
+	assert.Equal(t, 6, PrefixCount(list, "user:"))
+}
+
+func TestPrefixScanBytes(t *testing.T) {
+	list := NewFunc[[]byte, int](bytes.Compare)
+	for i, k := range []string{"user:12", "user:123:a", "user:123:b", "user:123;", "\xff", "\xff\xff"} {
+		list.Insert([]byte(k), i)
+	}
+	scan := func(prefix []byte) []string {
+		var r []string
+		for it := PrefixScan(list, prefix); it.Next(); {
+			r = append(r, string(it.Key()))
+		}
+		return r
+	}
+
+	prefix := []byte("user:123:")
+	assert.Equal(t, []string{"user:123:a", "user:123:b"}, scan(prefix))
+	assert.Equal(t, "user:123:", string(prefix), "the prefix is not modified")
+	assert.Equal(t, []string{"\xff", "\xff\xff"}, scan([]byte{0xff}))
+	assert.Empty(t, scan([]byte("users")))
+	assert.Equal(t, 6, PrefixCount(list, nil))
+	assert.Equal(t, 4, PrefixCount(list, []byte("user:")))
+
+	_, hi := PrefixBounds([]byte("a\xfe\xff"))
+	assert.Equal(t, Excluded([]byte("a\xff")), hi)
+}
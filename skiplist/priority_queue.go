@@ -0,0 +1,141 @@
+package skiplist
+
+import (
+	"cmp"
+	"math/bits"
+	"sync/atomic"
+
+	"github.com/crrow/reona/util"
+)
+
+// PriorityQueue A lock-free min priority queue on top of SkipList.
+//
+// Items with equal priorities are popped by PopMin in the order they were pushed, as long
+// as a single goroutine pushes them. Pushes racing with each other may be ordered either
+// way, and PopMinRelaxed does not keep any order.
+//
+// The empty value is not usable, use NewPriorityQueue or NewPriorityQueueFunc.
+type PriorityQueue[P any, T any] struct {
+	sl *SkipList[pqKey[P], T]
+	// Breaks ties between equal priorities.
+	seq atomic.Uint64
+	// Draws the jumps of PopMinRelaxed, apart from the tower heights of sl so that pops do
+	// not change the shape of seeded skip lists.
+	rng heightGen
+}
+
+type pqKey[P any] struct {
+	priority P
+	seq      uint64
+}
+
+// NewPriorityQueue Returns an empty priority queue, smaller priorities first, see Config
+// for the options.
+func NewPriorityQueue[P cmp.Ordered, T any](opts ...util.Option[Config]) *PriorityQueue[P, T] {
+	return NewPriorityQueueFunc[P, T](cmp.Compare[P], opts...)
+}
+
+// NewPriorityQueueFunc Returns an empty priority queue ordered by compare, see NewFunc.
+func NewPriorityQueueFunc[P any, T any](compare func(a, b P) int, opts ...util.Option[Config]) *PriorityQueue[P, T] {
+	q := &PriorityQueue[P, T]{
+		sl: NewFunc[pqKey[P], T](func(a, b pqKey[P]) int {
+			if c := compare(a.priority, b.priority); c != 0 {
+				return c
+			}
+			return cmp.Compare(a.seq, b.seq)
+		}, opts...),
+	}
+	q.rng.init(q.sl.heights.Config)
+	// another stream than the heights, still fixed by the seed
+	q.rng.state.Store(^q.sl.heights.seed)
+	return q
+}
+
+// Len Returns the number of items in the queue.
+//
+// If the queue is being concurrently modified, consider the returned number just an
+// approximation without any guarantees.
+func (q *PriorityQueue[P, T]) Len() uint64 {
+	return q.sl.Len()
+}
+
+// Push Adds an item to the queue.
+func (q *PriorityQueue[P, T]) Push(priority P, value T) {
+	q.sl.Insert(pqKey[P]{priority: priority, seq: q.seq.Add(1)}, value)
+}
+
+// PeekMin Returns the item with the smallest priority without removing it.
+func (q *PriorityQueue[P, T]) PeekMin() (P, T, bool) {
+	n, ok := q.sl.Front()
+	return pqItem(n, ok)
+}
+
+// PopMin Removes the item with the smallest priority and returns it.
+//
+// If several goroutines pop concurrently, every item is returned to exactly one of them.
+func (q *PriorityQueue[P, T]) PopMin() (P, T, bool) {
+	n, ok := q.sl.PopFront()
+	return pqItem(n, ok)
+}
+
+// PopMinRelaxed Removes one of the items with the smallest priorities and returns it, like
+// the SprayList of Alistarh et al.
+//
+// When many consumers pop at once they all fight for the first node, and all but one of
+// them fail and retry. A relaxed pop instead does a short random walk, a spray, from the
+// top of a low tower down to level 0 and takes the node it lands on, so concurrent
+// consumers mostly pick different nodes. With consumers goroutines popping, the item is
+// among the first O(consumers·log³ consumers) with high probability, and for 1 it is
+// always the smallest.
+func (q *PriorityQueue[P, T]) PopMinRelaxed(consumers int) (P, T, bool) {
+	if consumers > 1 {
+		// Collisions are rare, give up after a few and fall back to the exact pop.
+		for attempt := 0; attempt < 4; attempt++ {
+			n := q.spray(consumers)
+			if n == nil {
+				break
+			}
+			if q.sl.removeNode(n) {
+				return pqItem(n, true)
+			}
+		}
+	}
+	return q.PopMin()
+}
+
+// spray Walks from the head down to level 0, at each level jumping forward a random number
+// of nodes, and returns the node it lands on, nil if the queue looks empty.
+func (q *PriorityQueue[P, T]) spray(consumers int) *Node[pqKey[P], T] {
+	logP := bits.Len(uint(consumers))
+	// Start at level log p and jump up to log p nodes at every level, which spreads the
+	// landing points over roughly p·log² p nodes.
+	level := min(logP, q.sl.startLevel())
+	pred := &q.sl.head
+	var landed *Node[pqKey[P], T]
+	for level >= 1 {
+		level--
+		for jumps := q.rng.random() % uint64(logP+1); jumps > 0; jumps-- {
+			// Removed nodes are stepped over like any other, without helping to unlink
+			// them, the landing node is checked when removing it.
+			next := pred.pointers[level].Load().ptr()
+			if next == nil {
+				break
+			}
+			landed = next
+			pred = &next.tower
+		}
+	}
+	if landed == nil || landed.IsRemoved() {
+		landed, _ = q.sl.Front()
+	}
+	return landed
+}
+
+func pqItem[P any, T any](n *Node[pqKey[P], T], ok bool) (P, T, bool) {
+	if !ok {
+		var priority P
+		var value T
+		return priority, value, false
+	}
+	return n.key.priority, n.value, true
+}
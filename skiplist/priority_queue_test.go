@@ -0,0 +1,211 @@
+package skiplist
+
+import (
+	"container/heap"
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriorityQueue(t *testing.T) {
+	q := NewPriorityQueue[int, string]()
+	_, _, ok := q.PopMin()
+	assert.False(t, ok)
+
+	q.Push(3, "c")
+	q.Push(1, "a1")
+	q.Push(2, "b")
+	q.Push(1, "a2")
+	q.Push(1, "a3")
+	assert.EqualValues(t, 5, q.Len())
+	p, v, ok := q.PeekMin()
+	assert.True(t, ok)
+	assert.Equal(t, 1, p)
+	assert.Equal(t, "a1", v)
+
+	var got []string
+	for {
+		_, v, ok := q.PopMin()
+		if !ok {
+			break
+		}
+		got = append(got, v)
+	}
+	// equal priorities come out in the order they were pushed
+	assert.Equal(t, []string{"a1", "a2", "a3", "b", "c"}, got)
+	assert.EqualValues(t, 0, q.Len())
+
+	desc := NewPriorityQueueFunc[int, int](func(a, b int) int { return b - a })
+	for i := 0; i < 5; i++ {
+		desc.Push(i, i)
+	}
+	p, _, _ = desc.PopMin()
+	assert.Equal(t, 4, p)
+}
+
+func TestPriorityQueueRelaxed(t *testing.T) {
+	const n = 4096
+	q := NewPriorityQueue[int, int](WithSeed(5))
+	for i := 0; i < n; i++ {
+		q.Push(i, i)
+	}
+	// a single consumer always gets the minimum
+	p, _, _ := q.PopMinRelaxed(1)
+	assert.Equal(t, 0, p)
+
+	seen := make([]bool, n)
+	seen[0] = true
+	var worst int
+	for i := 1; i < n; i++ {
+		p, v, ok := q.PopMinRelaxed(8)
+		assert.True(t, ok)
+		assert.Equal(t, p, v)
+		assert.False(t, seen[p])
+		seen[p] = true
+		// count how many smaller items were still queued
+		skipped := 0
+		for j := 0; j < p; j++ {
+			if !seen[j] {
+				skipped++
+			}
+		}
+		worst = max(worst, skipped)
+	}
+	_, _, ok := q.PopMinRelaxed(8)
+	assert.False(t, ok)
+	assert.Less(t, worst, 8*4*4*4)
+}
+
+func TestPriorityQueueRelaxedKeepsHeights(t *testing.T) {
+	// Relaxed pops must not draw from the heights, or seeded queues would grow differently
+	// depending on how they were popped.
+	towers := func(pop bool) map[int]int {
+		q := NewPriorityQueue[int, int](WithSeed(7))
+		for i := 0; i < 100; i++ {
+			q.Push(i, i)
+			if pop && i%10 == 9 {
+				q.PopMinRelaxed(16)
+			}
+		}
+		heights := map[int]int{}
+		for it := q.sl.Range(Unbounded[pqKey[int]](), Unbounded[pqKey[int]]()); it.Next(); {
+			heights[it.Key().priority] = it.curr.Height()
+		}
+		return heights
+	}
+	all, popped := towers(false), towers(true)
+	assert.Len(t, popped, 90)
+	for p, h := range popped {
+		assert.Equal(t, all[p], h, p)
+	}
+}
+
+func TestPriorityQueueConcurrent(t *testing.T) {
+	const producers, consumers, perProducer = 4, 4, 2000
+	q := NewPriorityQueue[int, int]()
+	var popped [producers * perProducer]atomic.Int32
+	var done atomic.Int32
+	var wg sync.WaitGroup
+	wg.Add(producers + consumers)
+	for w := 0; w < producers; w++ {
+		go func(w int) {
+			defer wg.Done()
+			defer done.Add(1)
+			for i := 0; i < perProducer; i++ {
+				k := w*perProducer + i
+				q.Push(k%97, k)
+			}
+		}(w)
+	}
+	for w := 0; w < consumers; w++ {
+		go func(w int) {
+			defer wg.Done()
+			for {
+				var v int
+				var ok bool
+				if w%2 == 0 {
+					_, v, ok = q.PopMin()
+				} else {
+					_, v, ok = q.PopMinRelaxed(consumers)
+				}
+				if ok {
+					popped[v].Add(1)
+				} else if done.Load() == producers && q.Len() == 0 {
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	for i := range popped {
+		assert.EqualValues(t, 1, popped[i].Load(), "item %d", i)
+	}
+}
+
+type intHeap []int
+
+func (h intHeap) Len() int           { return len(h) }
+func (h intHeap) Less(i, j int) bool { return h[i] < h[j] }
+func (h intHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *intHeap) Push(x any)        { *h = append(*h, x.(int)) }
+func (h *intHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// lockedHeap A container/heap guarded by a mutex, the baseline for the benchmarks.
+type lockedHeap struct {
+	mu sync.Mutex
+	h  intHeap
+}
+
+func (l *lockedHeap) push(p int) {
+	l.mu.Lock()
+	heap.Push(&l.h, p)
+	l.mu.Unlock()
+}
+
+func (l *lockedHeap) pop() {
+	l.mu.Lock()
+	if l.h.Len() > 0 {
+		heap.Pop(&l.h)
+	}
+	l.mu.Unlock()
+}
+
+// benchmarkQueue Prefills a queue and then lets every goroutine push and pop alternately.
+func benchmarkQueue(b *testing.B, push func(int), pop func()) {
+	for i := 0; i < 1<<14; i++ {
+		push(rand.Intn(1 << 20))
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		rng := rand.New(rand.NewSource(rand.Int63()))
+		for pb.Next() {
+			push(rng.Intn(1 << 20))
+			pop()
+		}
+	})
+}
+
+func BenchmarkPriorityQueue(b *testing.B) {
+	q := NewPriorityQueue[int, struct{}]()
+	benchmarkQueue(b, func(p int) { q.Push(p, struct{}{}) }, func() { q.PopMin() })
+}
+
+func BenchmarkPriorityQueueRelaxed(b *testing.B) {
+	q := NewPriorityQueue[int, struct{}]()
+	consumers := max(2, runtime.GOMAXPROCS(0))
+	benchmarkQueue(b, func(p int) { q.Push(p, struct{}{}) }, func() { q.PopMinRelaxed(consumers) })
+}
+
+func BenchmarkLockedHeap(b *testing.B) {
+	var h lockedHeap
+	benchmarkQueue(b, h.push, h.pop)
+}
@@ -0,0 +1,201 @@
+package skiplist
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/crrow/reona/util"
+)
+
+var (
+	// ErrOverlap The range overlaps a range already in the RangeMap.
+	ErrOverlap = errors.New("skiplist: range overlaps an existing one")
+	// ErrEmptyRange The range has no keys, its lower end is not below its upper end.
+	ErrEmptyRange = errors.New("skiplist: empty range")
+)
+
+// Span A range of keys [Lo, Hi) and its value.
+type Span[K any, V any] struct {
+	Lo, Hi K
+	Value  V
+}
+
+// RangeMap A map from non-overlapping ranges of keys [lo, hi) to values, such as IP ranges
+// or time ranges to their owners.
+//
+// Ranges are kept in a SkipList keyed by their lower end, so the range containing a key
+// is the Floor of the key. Writers are serialized by a mutex, which keeps the check for
+// overlaps and the insertion atomic. Readers take no locks and every lookup sees each range
+// either before or after a concurrent write, never a gap in between: a lookup that races
+// a Split retries, see reaching.
+//
+// The empty value is not usable, use NewRangeMap or NewRangeMapFunc.
+type RangeMap[K any, V any] struct {
+	mu      sync.Mutex
+	sl      *SkipList[K, *rangeSlot[K, V]]
+	compare func(a, b K) int
+}
+
+// rangeSlot Holds the range starting at a key, split and merge change its upper end in
+// place instead of replacing the node.
+type rangeSlot[K any, V any] struct {
+	span atomic.Pointer[Span[K, V]]
+}
+
+// NewRangeMap Returns an empty range map ordered by the natural order of the keys, see
+// Config for the options.
+func NewRangeMap[K cmp.Ordered, V any](opts ...util.Option[Config]) *RangeMap[K, V] {
+	return NewRangeMapFunc[K, V](cmp.Compare[K], opts...)
+}
+
+// NewRangeMapFunc Returns an empty range map ordered by compare, see NewFunc.
+func NewRangeMapFunc[K any, V any](compare func(a, b K) int, opts ...util.Option[Config]) *RangeMap[K, V] {
+	return &RangeMap[K, V]{sl: NewFunc[K, *rangeSlot[K, V]](compare, opts...), compare: compare}
+}
+
+// Len Returns the number of ranges.
+func (m *RangeMap[K, V]) Len() uint64 {
+	return m.sl.Len()
+}
+
+// Insert Maps the keys in [lo, hi) to value, returns ErrOverlap if any of them is mapped
+// already.
+func (m *RangeMap[K, V]) Insert(lo, hi K, value V) error {
+	if m.compare(lo, hi) >= 0 {
+		return fmt.Errorf("%w: [%v, %v)", ErrEmptyRange, lo, hi)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if prev, ok := m.sl.Floor(lo); ok {
+		if span := prev.value.span.Load(); m.compare(lo, span.Hi) < 0 {
+			return fmt.Errorf("%w: [%v, %v) and [%v, %v)", ErrOverlap, lo, hi, span.Lo, span.Hi)
+		}
+	}
+	if next, ok := m.sl.Higher(lo); ok && m.compare(next.key, hi) < 0 {
+		span := next.value.span.Load()
+		return fmt.Errorf("%w: [%v, %v) and [%v, %v)", ErrOverlap, lo, hi, span.Lo, span.Hi)
+	}
+	slot := &rangeSlot[K, V]{}
+	slot.span.Store(&Span[K, V]{Lo: lo, Hi: hi, Value: value})
+	m.sl.Insert(lo, slot)
+	return nil
+}
+
+// Get Returns the range containing key.
+func (m *RangeMap[K, V]) Get(key K) (Span[K, V], bool) {
+	if span, ok := m.reaching(key, m.sl.Floor); ok {
+		return *span, true
+	}
+	return Span[K, V]{}, false
+}
+
+// reaching Returns the range of the node that search finds for key, if it reaches above key.
+//
+// A reader can find a node just before Split links the upper half of its range and shrinks
+// it, and then load the shrunk range, which ends at or below a key that stays mapped. So
+// such a range is looked up again, until search finds the same node twice.
+func (m *RangeMap[K, V]) reaching(key K, search func(key K) (*Node[K, *rangeSlot[K, V]], bool)) (*Span[K, V], bool) {
+	var last *Node[K, *rangeSlot[K, V]]
+	for {
+		n, ok := search(key)
+		if !ok || n == last {
+			return nil, false
+		}
+		if span := n.value.span.Load(); m.compare(key, span.Hi) < 0 {
+			return span, true
+		}
+		last = n
+	}
+}
+
+// Remove Removes the range starting at lo, returns `false` if there was none.
+func (m *RangeMap[K, V]) Remove(lo K) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sl.Remove(lo)
+}
+
+// Split Splits the range [lo, hi) containing at into [lo, at) and [at, hi), both with the
+// value of the original range. Returns `false` if no range contains at or one starts at it.
+func (m *RangeMap[K, V]) Split(at K) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n, ok := m.sl.Floor(at)
+	if !ok {
+		return false
+	}
+	span := n.value.span.Load()
+	if m.compare(span.Lo, at) == 0 || m.compare(at, span.Hi) >= 0 {
+		return false
+	}
+	// Link the upper half first: until the lower half shrinks, keys in [at, hi) are found
+	// in either one with the same value.
+	upper := &rangeSlot[K, V]{}
+	upper.span.Store(&Span[K, V]{Lo: at, Hi: span.Hi, Value: span.Value})
+	m.sl.Insert(at, upper)
+	n.value.span.Store(&Span[K, V]{Lo: span.Lo, Hi: at, Value: span.Value})
+	return true
+}
+
+// Merge Merges the range ending at at with the adjacent range starting at at. The merged
+// range keeps the value of the lower one. Returns `false` unless both ranges exist.
+func (m *RangeMap[K, V]) Merge(at K) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	upper, ok := m.sl.Get(at)
+	if !ok {
+		return false
+	}
+	n, ok := m.sl.Lower(at)
+	if !ok {
+		return false
+	}
+	lower := n.value.span.Load()
+	if m.compare(lower.Hi, at) != 0 {
+		return false
+	}
+	// Grow the lower range first: until the upper one is removed, keys in it are found in
+	// the upper range, and afterwards in the lower one.
+	n.value.span.Store(&Span[K, V]{Lo: lower.Lo, Hi: upper.span.Load().Hi, Value: lower.Value})
+	m.sl.Remove(at)
+	return true
+}
+
+// Spans Returns an iterator over the ranges that overlap [lo, hi), in ascending order.
+func (m *RangeMap[K, V]) Spans(lo, hi K) *SpanIter[K, V] {
+	it := &SpanIter[K, V]{it: m.sl.Range(Included(lo), Excluded(hi))}
+	// A range starting below lo may reach into [lo, hi).
+	if span, ok := m.reaching(lo, m.sl.Lower); ok {
+		it.first = span
+	}
+	return it
+}
+
+// SpanIter An iterator over ranges of a RangeMap.
+type SpanIter[K any, V any] struct {
+	it *Iter[K, *rangeSlot[K, V]]
+	// the range below the start of the iterator that overlaps it, yielded first
+	first *Span[K, V]
+	curr  *Span[K, V]
+}
+
+// Next Advances to the next range and returns `false` once there are no more.
+func (it *SpanIter[K, V]) Next() bool {
+	if it.first != nil {
+		it.curr, it.first = it.first, nil
+		return true
+	}
+	if !it.it.Next() {
+		return false
+	}
+	it.curr = it.it.Value().span.Load()
+	return true
+}
+
+// Span Returns the current range.
+func (it *SpanIter[K, V]) Span() Span[K, V] {
+	return *it.curr
+}
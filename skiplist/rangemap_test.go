@@ -0,0 +1,196 @@
+package skiplist
+
+import (
+	"net/netip"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func spans[K any, V any](it *SpanIter[K, V]) []Span[K, V] {
+	var r []Span[K, V]
+	for it.Next() {
+		r = append(r, it.Span())
+	}
+	return r
+}
+
+func TestRangeMap(t *testing.T) {
+	m := NewRangeMap[int, string]()
+	assert.NoError(t, m.Insert(10, 20, "a"))
+	assert.NoError(t, m.Insert(20, 30, "b"))
+	assert.NoError(t, m.Insert(40, 50, "c"))
+	assert.EqualValues(t, 3, m.Len())
+
+	assert.ErrorIs(t, m.Insert(5, 11, "x"), ErrOverlap)
+	assert.ErrorIs(t, m.Insert(29, 41, "x"), ErrOverlap)
+	assert.ErrorIs(t, m.Insert(10, 20, "x"), ErrOverlap)
+	assert.ErrorIs(t, m.Insert(12, 15, "x"), ErrOverlap)
+	assert.ErrorIs(t, m.Insert(0, 100, "x"), ErrOverlap)
+	assert.ErrorIs(t, m.Insert(35, 35, "x"), ErrEmptyRange)
+	assert.NoError(t, m.Insert(30, 40, "d"))
+
+	for _, tt := range []struct {
+		key  int
+		want string
+		ok   bool
+	}{{9, "", false}, {10, "a", true}, {19, "a", true}, {20, "b", true}, {35, "d", true}, {49, "c", true}, {50, "", false}} {
+		span, ok := m.Get(tt.key)
+		assert.Equal(t, tt.ok, ok, tt.key)
+		assert.Equal(t, tt.want, span.Value, tt.key)
+	}
+
+	assert.Equal(t, []Span[int, string]{{20, 30, "b"}, {30, 40, "d"}}, spans(m.Spans(25, 40)))
+	assert.Equal(t, []Span[int, string]{{10, 20, "a"}}, spans(m.Spans(0, 11)))
+	assert.Empty(t, spans(m.Spans(50, 60)))
+
+	assert.True(t, m.Remove(30))
+	assert.False(t, m.Remove(30))
+	_, ok := m.Get(35)
+	assert.False(t, ok)
+}
+
+func TestRangeMapSplitMerge(t *testing.T) {
+	m := NewRangeMap[int, string]()
+	assert.NoError(t, m.Insert(0, 100, "a"))
+
+	assert.False(t, m.Split(0))
+	assert.False(t, m.Split(100))
+	assert.True(t, m.Split(50))
+	assert.Equal(t, []Span[int, string]{{0, 50, "a"}, {50, 100, "a"}}, spans(m.Spans(0, 100)))
+
+	assert.NoError(t, m.Insert(100, 120, "b"))
+	assert.True(t, m.Merge(100))
+	span, ok := m.Get(110)
+	assert.True(t, ok)
+	assert.Equal(t, Span[int, string]{50, 120, "a"}, span)
+
+	assert.False(t, m.Merge(0))
+	assert.False(t, m.Merge(75))
+	assert.NoError(t, m.Insert(130, 140, "c"))
+	// not adjacent
+	assert.False(t, m.Merge(130))
+
+	assert.True(t, m.Merge(50))
+	assert.Equal(t, []Span[int, string]{{0, 120, "a"}, {130, 140, "c"}}, spans(m.Spans(0, 200)))
+}
+
+func TestRangeMapFunc(t *testing.T) {
+	m := NewRangeMapFunc[netip.Addr, string](netip.Addr.Compare)
+	assert.NoError(t, m.Insert(netip.MustParseAddr("10.0.0.0"), netip.MustParseAddr("10.1.0.0"), "office"))
+	assert.NoError(t, m.Insert(netip.MustParseAddr("192.168.0.0"), netip.MustParseAddr("192.169.0.0"), "lab"))
+
+	span, ok := m.Get(netip.MustParseAddr("10.0.255.1"))
+	assert.True(t, ok)
+	assert.Equal(t, "office", span.Value)
+	_, ok = m.Get(netip.MustParseAddr("10.1.0.0"))
+	assert.False(t, ok)
+}
+
+func TestRangeMapConcurrent(t *testing.T) {
+	// Keys in [0, 1000) are always mapped while a writer splits and merges the range, and
+	// readers must never miss one.
+	m := NewRangeMap[int, int]()
+	assert.NoError(t, m.Insert(0, 1000, 7))
+
+	var done atomic.Bool
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer done.Store(true)
+		for i := 0; i < 200; i++ {
+			at := 1 + i*997%999
+			assert.True(t, m.Split(at))
+			runtime.Gosched()
+			assert.True(t, m.Merge(at))
+		}
+	}()
+
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func(r int) {
+			defer wg.Done()
+			for i := r; !done.Load(); i += 7 {
+				span, ok := m.Get(i % 1000)
+				if !assert.True(t, ok) || !assert.Equal(t, 7, span.Value) {
+					return
+				}
+				runtime.Gosched()
+			}
+		}(r)
+	}
+	wg.Wait()
+	assert.EqualValues(t, 1, m.Len())
+}
+
+func TestRangeMapStaleFloor(t *testing.T) {
+	// A reader finds the range, then Split links the upper half and shrinks the range
+	// before the reader loads it. The key stays mapped, so the reader must look again.
+	m := NewRangeMap[int, int]()
+	assert.NoError(t, m.Insert(0, 100, 7))
+	split := func(at int) func(key int) (*Node[int, *rangeSlot[int, int]], bool) {
+		done := false
+		return func(key int) (*Node[int, *rangeSlot[int, int]], bool) {
+			n, ok := m.sl.Floor(key)
+			if !done {
+				done = true
+				assert.True(t, m.Split(at))
+			}
+			return n, ok
+		}
+	}
+	span, ok := m.reaching(50, split(30))
+	if assert.True(t, ok) {
+		assert.Equal(t, Span[int, int]{Lo: 30, Hi: 100, Value: 7}, *span)
+	}
+	span, ok = m.reaching(50, split(50))
+	if assert.True(t, ok) {
+		assert.Equal(t, Span[int, int]{Lo: 50, Hi: 100, Value: 7}, *span)
+	}
+
+	// A key in a gap is still not found, even though the retry finds the same node.
+	assert.NoError(t, m.Insert(200, 300, 8))
+	_, ok = m.Get(150)
+	assert.False(t, ok)
+}
+
+func TestRangeMapConcurrentSplit(t *testing.T) {
+	// A writer splits [0, n) at every key while readers look keys up, all of them stay
+	// mapped throughout.
+	const n = 1 << 12
+	m := NewRangeMap[int, int]()
+	assert.NoError(t, m.Insert(0, n, 7))
+
+	var done atomic.Bool
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer done.Store(true)
+		for i := 1; i < n; i++ {
+			assert.True(t, m.Split(i*1021%n))
+		}
+	}()
+
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func(r int) {
+			defer wg.Done()
+			for i := r; !done.Load(); i += 7 {
+				k := i % n
+				if _, ok := m.Get(k); !assert.True(t, ok, k) {
+					return
+				}
+				if got := spans(m.Spans(k, k+1)); !assert.Len(t, got, 1, k) {
+					return
+				}
+			}
+		}(r)
+	}
+	wg.Wait()
+	assert.EqualValues(t, n, m.Len())
+}
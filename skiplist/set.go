@@ -0,0 +1,182 @@
+package skiplist
+
+import (
+	"cmp"
+
+	"github.com/crrow/reona/util"
+)
+
+// SkipSet A lock-free sorted set.
+//
+// A SkipSet is a SkipList whose values are empty structs: it shares all of the skip list
+// code, and the values take no space in the nodes.
+//
+// The empty value is not usable, use NewSet or NewSetFunc.
+type SkipSet[K any] SkipList[K, struct{}]
+
+// NewSet Returns an empty set ordered by the natural order of the keys, see Config for the
+// options.
+func NewSet[K cmp.Ordered](opts ...util.Option[Config]) *SkipSet[K] {
+	return (*SkipSet[K])(New[K, struct{}](opts...))
+}
+
+// NewSetFunc Returns an empty set ordered by compare, see NewFunc.
+func NewSetFunc[K any](compare func(a, b K) int, opts ...util.Option[Config]) *SkipSet[K] {
+	return (*SkipSet[K])(NewFunc[K, struct{}](compare, opts...))
+}
+
+func (s *SkipSet[K]) list() *SkipList[K, struct{}] {
+	return (*SkipList[K, struct{}])(s)
+}
+
+// Len Returns the number of keys in the set.
+//
+// If the set is being concurrently modified, consider the returned number just an
+// approximation without any guarantees.
+func (s *SkipSet[K]) Len() uint64 {
+	return s.list().Len()
+}
+
+// IsEmpty Returns `true` if the set is empty.
+func (s *SkipSet[K]) IsEmpty() bool {
+	return s.list().IsEmpty()
+}
+
+// Add Adds the key to the set, returns `false` if it was already there.
+func (s *SkipSet[K]) Add(key K) bool {
+	n, added := s.list().doInsert(key, func() struct{} { return struct{}{} }, false)
+	n.decrement()
+	return added
+}
+
+// Contains Returns whether the key is in the set.
+func (s *SkipSet[K]) Contains(key K) bool {
+	_, ok := s.list().Get(key)
+	return ok
+}
+
+// Remove Removes the key from the set, returns `false` if it was not there.
+func (s *SkipSet[K]) Remove(key K) bool {
+	return s.list().Remove(key)
+}
+
+// Range Returns an iterator over the keys between lo and hi.
+func (s *SkipSet[K]) Range(lo, hi Bound[K]) *Iter[K, struct{}] {
+	return s.list().Range(lo, hi)
+}
+
+// Descend Returns an iterator over the keys below from, largest key first.
+func (s *SkipSet[K]) Descend(from Bound[K]) *Iter[K, struct{}] {
+	return s.list().Descend(from)
+}
+
+// setOp The operation of a SetIter.
+type setOp uint8
+
+const (
+	union setOp = iota
+	intersect
+	difference
+)
+
+// SetIter An iterator over the result of a set operation, in ascending key order.
+//
+// The result is computed while iterating, by walking both sets side by side in linear
+// time. Like Iter it is weakly consistent while the sets are modified. It yields empty
+// values too, so that it can be passed to FromSorted or Merge.
+type SetIter[K any] struct {
+	a, b     *Iter[K, struct{}]
+	compare  func(a, b K) int
+	op       setOp
+	started  bool
+	aOK, bOK bool
+	key      K
+}
+
+// Union Returns an iterator over the keys in s or in other.
+//
+// Both sets must be ordered the same way.
+func (s *SkipSet[K]) Union(other *SkipSet[K]) *SetIter[K] {
+	return s.setIter(other, union)
+}
+
+// Intersect Returns an iterator over the keys in both s and other.
+//
+// Both sets must be ordered the same way.
+func (s *SkipSet[K]) Intersect(other *SkipSet[K]) *SetIter[K] {
+	return s.setIter(other, intersect)
+}
+
+// Difference Returns an iterator over the keys in s but not in other.
+//
+// Both sets must be ordered the same way.
+func (s *SkipSet[K]) Difference(other *SkipSet[K]) *SetIter[K] {
+	return s.setIter(other, difference)
+}
+
+func (s *SkipSet[K]) setIter(other *SkipSet[K], op setOp) *SetIter[K] {
+	all := Unbounded[K]()
+	return &SetIter[K]{
+		a:       s.Range(all, all),
+		b:       other.Range(all, all),
+		compare: s.compare,
+		op:      op,
+	}
+}
+
+// Next Advances to the next key and returns `false` once the result is exhausted.
+func (it *SetIter[K]) Next() bool {
+	if !it.started {
+		it.started = true
+		it.aOK, it.bOK = it.a.Next(), it.b.Next()
+	}
+	for it.aOK || it.bOK {
+		// which of the two current keys is smaller, a missing key counts as larger
+		var order int
+		switch {
+		case !it.bOK:
+			order = -1
+		case !it.aOK:
+			order = 1
+		default:
+			order = it.compare(it.a.Key(), it.b.Key())
+		}
+
+		var yield bool
+		switch it.op {
+		case union:
+			yield = true
+		case intersect:
+			yield = order == 0
+		case difference:
+			yield = order < 0
+		}
+		// step past the smaller key, or both if they are equal
+		if order <= 0 {
+			it.key = it.a.Key()
+			it.aOK = it.a.Next()
+		}
+		if order >= 0 {
+			it.key = it.b.Key()
+			it.bOK = it.b.Next()
+		}
+		if yield {
+			return true
+		}
+		// Nothing left to yield once a set is exhausted.
+		if it.op == intersect && !(it.aOK && it.bOK) || it.op == difference && !it.aOK {
+			return false
+		}
+	}
+	return false
+}
+
+// Key Returns the current key.
+func (it *SetIter[K]) Key() K {
+	return it.key
+}
+
+// Value Returns the empty value.
+func (it *SetIter[K]) Value() struct{} {
+	return struct{}{}
+}
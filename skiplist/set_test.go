@@ -0,0 +1,107 @@
+package skiplist
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setKeys(it interface {
+	Next() bool
+	Key() int
+}) []int {
+	var r []int
+	for it.Next() {
+		r = append(r, it.Key())
+	}
+	return r
+}
+
+func newSet(keys ...int) *SkipSet[int] {
+	s := NewSet[int]()
+	for _, k := range keys {
+		s.Add(k)
+	}
+	return s
+}
+
+func TestSkipSet(t *testing.T) {
+	s := NewSet[int]()
+	assert.True(t, s.IsEmpty())
+	assert.True(t, s.Add(3))
+	assert.True(t, s.Add(1))
+	assert.False(t, s.Add(3))
+	assert.True(t, s.Add(2))
+	assert.EqualValues(t, 3, s.Len())
+	assert.True(t, s.Contains(2))
+	assert.False(t, s.Contains(4))
+	assert.Equal(t, []int{1, 2, 3}, setKeys(s.Range(Unbounded[int](), Unbounded[int]())))
+	assert.Equal(t, []int{2, 1}, setKeys(s.Descend(Excluded(3))))
+	assert.True(t, s.Remove(2))
+	assert.False(t, s.Remove(2))
+	assert.False(t, s.Contains(2))
+	assert.EqualValues(t, 2, s.Len())
+	checkTowers(t, (*SkipList[int, struct{}])(s))
+
+	words := NewSetFunc[string](func(a, b string) int { return len(a) - len(b) })
+	assert.True(t, words.Add("ccc"))
+	assert.True(t, words.Add("a"))
+	assert.False(t, words.Add("b"))
+	assert.True(t, words.Contains("z"))
+}
+
+func TestSetOperations(t *testing.T) {
+	a := newSet(1, 2, 4, 6, 8, 9)
+	b := newSet(2, 3, 4, 9, 10)
+	empty := newSet()
+
+	assert.Equal(t, []int{1, 2, 3, 4, 6, 8, 9, 10}, setKeys(a.Union(b)))
+	assert.Equal(t, []int{2, 4, 9}, setKeys(a.Intersect(b)))
+	assert.Equal(t, []int{1, 6, 8}, setKeys(a.Difference(b)))
+	assert.Equal(t, []int{3, 10}, setKeys(b.Difference(a)))
+
+	assert.Equal(t, setKeys(a.Range(Unbounded[int](), Unbounded[int]())), setKeys(a.Union(empty)))
+	assert.Equal(t, setKeys(a.Range(Unbounded[int](), Unbounded[int]())), setKeys(empty.Union(a)))
+	assert.Equal(t, setKeys(a.Range(Unbounded[int](), Unbounded[int]())), setKeys(a.Difference(empty)))
+	assert.Nil(t, setKeys(a.Intersect(empty)))
+	assert.Nil(t, setKeys(empty.Difference(a)))
+	assert.Nil(t, setKeys(a.Difference(a)))
+	assert.Equal(t, setKeys(a.Range(Unbounded[int](), Unbounded[int]())), setKeys(a.Intersect(a)))
+
+	it := a.Intersect(b)
+	assert.Equal(t, []int{2, 4, 9}, setKeys(it))
+	assert.False(t, it.Next())
+
+	// results can be materialized
+	u, err := FromSorted[int, struct{}](a.Union(b))
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3, 4, 6, 8, 9, 10}, keys(u))
+}
+
+func TestSkipSetConcurrent(t *testing.T) {
+	const workers, n = 4, 1000
+	s := NewSet[int]()
+	var added [n]int32
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				if s.Add(i) {
+					mu.Lock()
+					added[i]++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	// every key is added by exactly one goroutine
+	for i := range added {
+		assert.EqualValues(t, 1, added[i])
+	}
+	assert.EqualValues(t, n, s.Len())
+}
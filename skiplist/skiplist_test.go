@@ -1,21 +1,366 @@
-//go:build exclude
-
 package skiplist
 
 import (
+	"bytes"
+	"cmp"
 	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
+// keys walks level 0 and returns the keys of all nodes that are not removed.
+func keys[K any, V any](sl *SkipList[K, V]) []K {
+	var r []K
+	for n := sl.head.pointers[0].Load().ptr(); n != nil; n = n.tower.pointers[0].Load().ptr() {
+		if !n.IsRemoved() {
+			r = append(r, n.key)
+		}
+	}
+	return r
+}
+
+// checkTowers verifies that every level is sorted and only links nodes that are also
+// linked at the level below.
+func checkTowers[V any](t *testing.T, sl *SkipList[int, V]) {
+	below := map[*Node[int, V]]bool{}
+	for level := 0; level < len(sl.head.pointers); level++ {
+		here := map[*Node[int, V]]bool{}
+		prev := -1 << 63
+		for n := sl.head.pointers[level].Load().ptr(); n != nil; n = n.tower.pointers[level].Load().ptr() {
+			assert.Greater(t, n.key, prev, "level %d is not sorted", level)
+			assert.Greater(t, n.Height(), level)
+			if level > 0 {
+				assert.True(t, below[n], "key %d is linked at level %d only", n.key, level)
+			}
+			prev = n.key
+			here[n] = true
+		}
+		below = here
+	}
+}
+
 func TestNewSkipList(t *testing.T) {
 	list := New[int, int]()
 	assert.Equal(t, list.IsEmpty(), true)
 
-	pos := list.searchPosition(1)
-	fmt.Println(pos)
-	//n, ok := list.Front()
-	//assert.False(t, ok)
-	//assert.Nil(t, n)
+	n, ok := list.Front()
+	assert.False(t, ok)
+	assert.Nil(t, n)
+
+	v, ok := list.Get(1)
+	assert.False(t, ok)
+	assert.Zero(t, v)
+	assert.False(t, list.Remove(1))
+}
+
+func TestInsertGetRemove(t *testing.T) {
+	list := New[int, string]()
+	list.Insert(2, "b")
+	list.Insert(1, "a")
+	list.Insert(3, "c")
+	assert.EqualValues(t, 3, list.Len())
+	assert.Equal(t, []int{1, 2, 3}, keys(list))
+
+	v, ok := list.Get(2)
+	assert.True(t, ok)
+	assert.Equal(t, "b", v)
+
+	list.Insert(2, "B")
+	assert.EqualValues(t, 3, list.Len())
+	v, _ = list.Get(2)
+	assert.Equal(t, "B", v)
+
+	n, ok := list.Front()
+	assert.True(t, ok)
+	assert.Equal(t, 1, n.Key())
+	assert.Equal(t, "a", n.Value())
+
+	assert.True(t, list.Remove(1))
+	assert.False(t, list.Remove(1))
+	assert.True(t, n.IsRemoved())
+	_, ok = list.Get(1)
+	assert.False(t, ok)
+	n, _ = list.Front()
+	assert.Equal(t, 2, n.Key())
+	assert.EqualValues(t, 2, list.Len())
+}
+
+func TestRandomAgainstMap(t *testing.T) {
+	list := New[int, int]()
+	model := map[int]int{}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20000; i++ {
+		k := rng.Intn(1000)
+		switch rng.Intn(3) {
+		case 0, 1:
+			list.Insert(k, i)
+			model[k] = i
+		default:
+			_, want := model[k]
+			assert.Equal(t, want, list.Remove(k))
+			delete(model, k)
+		}
+	}
+	want := make([]int, 0, len(model))
+	for k, v := range model {
+		want = append(want, k)
+		got, ok := list.Get(k)
+		assert.True(t, ok)
+		assert.Equal(t, v, got)
+	}
+	sort.Ints(want)
+	assert.Equal(t, want, keys(list))
+	assert.EqualValues(t, len(model), list.Len())
+	checkTowers(t, list)
+}
+
+func TestConcurrentInsertRemove(t *testing.T) {
+	const (
+		workers = 8
+		perKey  = 2000
+	)
+	list := New[int, int]()
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func(w int) {
+			defer wg.Done()
+			// every worker owns the keys k with k % workers == w and keeps the odd ones
+			for i := 0; i < perKey; i++ {
+				k := i*workers + w
+				list.Insert(k, w)
+				if i%2 == 0 {
+					assert.True(t, list.Remove(k))
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	var want []int
+	for i := 1; i < perKey; i += 2 {
+		for w := 0; w < workers; w++ {
+			want = append(want, i*workers+w)
+		}
+	}
+	sort.Ints(want)
+	assert.Equal(t, want, keys(list))
+	assert.EqualValues(t, len(want), list.Len())
+	checkTowers(t, list)
+}
+
+func TestConcurrentSameKeys(t *testing.T) {
+	const workers = 8
+	list := New[int, int]()
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func(w int) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(int64(w)))
+			for i := 0; i < 5000; i++ {
+				k := rng.Intn(64)
+				switch rng.Intn(3) {
+				case 0:
+					list.Insert(k, w)
+				case 1:
+					list.Remove(k)
+				default:
+					if v, ok := list.Get(k); ok {
+						assert.Less(t, v, workers)
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	// quiescent now: len and contents must agree, keys must be unique and sorted
+	ks := keys(list)
+	assert.EqualValues(t, len(ks), list.Len())
+	assert.True(t, sort.IntsAreSorted(ks))
+	for i := 1; i < len(ks); i++ {
+		assert.NotEqual(t, ks[i-1], ks[i])
+	}
+	checkTowers(t, list)
+}
+
+func TestGetOrInsert(t *testing.T) {
+	list := New[int, string]()
+	calls := 0
+	value := func(v string) func() string {
+		return func() string {
+			calls++
+			return v
+		}
+	}
+
+	n, inserted := list.GetOrInsert(1, value("a"))
+	assert.True(t, inserted)
+	assert.Equal(t, "a", n.Value())
+	n, inserted = list.GetOrInsert(1, value("b"))
+	assert.False(t, inserted)
+	assert.Equal(t, "a", n.Value())
+	assert.Equal(t, 1, calls)
+
+	assert.False(t, list.InsertIfAbsent(1, "c"))
+	assert.True(t, list.InsertIfAbsent(2, "c"))
+	v, _ := list.Get(1)
+	assert.Equal(t, "a", v)
+	assert.EqualValues(t, 2, list.Len())
+
+	list.Remove(1)
+	n, inserted = list.GetOrInsert(1, value("d"))
+	assert.True(t, inserted)
+	assert.Equal(t, "d", n.Value())
+	checkTowers(t, list)
+}
+
+func TestConcurrentGetOrInsert(t *testing.T) {
+	// Every goroutine interns the same keys, all must agree on the winning values.
+	const goroutines, n = 8, 1000
+	list := New[int, *int]()
+	var wg sync.WaitGroup
+	results := make([][]*int, goroutines)
+	inserted := make([]int, goroutines)
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				node, ok := list.GetOrInsert(i, func() *int { return new(int) })
+				results[g] = append(results[g], node.Value())
+				if ok {
+					inserted[g]++
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	total := 0
+	for g := 0; g < goroutines; g++ {
+		total += inserted[g]
+		for i := 0; i < n; i++ {
+			v, _ := list.Get(i)
+			assert.Same(t, v, results[g][i])
+		}
+	}
+	assert.Equal(t, n, total)
+	assert.EqualValues(t, n, list.Len())
+	checkTowers(t, list)
+}
+
+func TestNewFunc(t *testing.T) {
+	list := NewFunc[[]byte, int](bytes.Compare)
+	for i, k := range []string{"b", "ab", "", "a", "b\x00", "\xff"} {
+		list.Insert([]byte(k), i)
+	}
+	list.Insert([]byte("ab"), 10)
+	assert.Equal(t, [][]byte{{}, []byte("a"), []byte("ab"), []byte("b"), []byte("b\x00"), []byte("\xff")}, keys(list))
+	v, ok := list.Get([]byte("ab"))
+	assert.True(t, ok)
+	assert.Equal(t, 10, v)
+	n, ok := list.Higher([]byte("b"))
+	assert.True(t, ok)
+	assert.Equal(t, []byte("b\x00"), n.Key())
+	assert.True(t, list.Remove([]byte("a")))
+	assert.False(t, list.Remove([]byte("a")))
+
+	// compare only has to get the sign right
+	desc := NewFunc[int, int](func(a, b int) int { return 10 * (b - a) }, WithSeed(0))
+	for i := 0; i < 100; i++ {
+		desc.Insert(i*7%100, i)
+	}
+	ks := keys(desc)
+	assert.Len(t, ks, 100)
+	assert.True(t, sort.IsSorted(sort.Reverse(sort.IntSlice(ks))))
+
+	// composite keys, newest version of a key first
+	type version struct {
+		key string
+		seq int
+	}
+	versions := NewFunc[version, int](func(a, b version) int {
+		if c := cmp.Compare(a.key, b.key); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.seq, a.seq)
+	})
+	for seq, k := range []string{"x", "y", "x", "x", "y"} {
+		versions.Insert(version{k, seq}, seq)
+	}
+	var got []version
+	for it := versions.Range(Included(version{"x", 2}), Unbounded[version]()); it.Next(); {
+		got = append(got, it.Key())
+	}
+	assert.Equal(t, []version{{"x", 2}, {"x", 0}, {"y", 4}, {"y", 1}}, got)
+}
+
+// descendFunc Returns the first node with a key >= key, comparing through sl.compare.
+func descendFunc(sl *SkipList[int, int], key int) *Node[int, int] {
+	var found *Node[int, int]
+	pred := &sl.head
+	for level := sl.startLevel() - 1; level >= 0; level-- {
+		for curr := pred.pointers[level].Load().ptr(); curr != nil; curr = pred.pointers[level].Load().ptr() {
+			if sl.compare(curr.key, key) >= 0 {
+				found = curr
+				break
+			}
+			pred = &curr.tower
+		}
+	}
+	return found
+}
+
+// descendInlined Is descendFunc with cmp.Compare inlined.
+func descendInlined(sl *SkipList[int, int], key int) *Node[int, int] {
+	var found *Node[int, int]
+	pred := &sl.head
+	for level := sl.startLevel() - 1; level >= 0; level-- {
+		for curr := pred.pointers[level].Load().ptr(); curr != nil; curr = pred.pointers[level].Load().ptr() {
+			if cmp.Compare(curr.key, key) >= 0 {
+				found = curr
+				break
+			}
+			pred = &curr.tower
+		}
+	}
+	return found
+}
+
+// BenchmarkCompare Measures what New pays for ordering keys through a compare function:
+// the same descent once calling sl.compare like the skip list does and once with
+// cmp.Compare inlined, as it was when keys had to be cmp.Ordered.
+func BenchmarkCompare(b *testing.B) {
+	for _, n := range []int{1 << 10, 1 << 16} {
+		sl := New[int, int]()
+		for i := 0; i < n; i++ {
+			sl.Insert(i*2, i)
+		}
+		probes := make([]int, 4096)
+		rng := rand.New(rand.NewSource(1))
+		for i := range probes {
+			probes[i] = rng.Intn(2*n - 1)
+		}
+		for _, bm := range []struct {
+			name    string
+			descend func(sl *SkipList[int, int], key int) *Node[int, int]
+		}{
+			{"func", descendFunc},
+			{"inlined", descendInlined},
+		} {
+			b.Run(fmt.Sprintf("%s/%d", bm.name, n), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					if bm.descend(sl, probes[i%len(probes)]) == nil {
+						b.Fatal("every probe has a key >= it")
+					}
+				}
+			})
+		}
+	}
 }
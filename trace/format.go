@@ -0,0 +1,218 @@
+// Package trace records the operations issued against a structure into a compact binary
+// trace, and replays such a trace against any structure.
+//
+// A trace starts with a header:
+//
+//	magic "RTRC" | version byte | key kind byte
+//
+// followed by one record per operation:
+//
+//	op byte | goroutine uvarint | time delta varint | key | value size uvarint (inserts only)
+//
+// The time delta is the distance in nanoseconds to the timestamp of the previous record,
+// it may be negative since concurrent operations finish in a different order than they
+// start. Integer keys are varints, floats their IEEE 754 bits, strings are length prefixed.
+package trace
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"time"
+)
+
+const (
+	magic   = "RTRC"
+	version = 1
+	// maxKeySize bounds the length of a string key, so that a corrupt length does not
+	// allocate gigabytes.
+	maxKeySize = 1 << 20
+	// maxValueSize bounds the recorded size of a value, replaying hands it to a function
+	// that typically allocates that many bytes.
+	maxValueSize = 1 << 26
+)
+
+var (
+	ErrBadMagic     = errors.New("trace: not a trace file")
+	ErrBadVersion   = errors.New("trace: unsupported version")
+	ErrKeyKind      = errors.New("trace: key type does not match the trace")
+	ErrKeyTooLong   = errors.New("trace: key too long")
+	ErrValueTooLong = errors.New("trace: value size too large")
+)
+
+// Op is the kind of a recorded operation.
+type Op uint8
+
+const (
+	OpInsert Op = iota + 1
+	OpGet
+	OpRemove
+)
+
+func (o Op) String() string {
+	switch o {
+	case OpInsert:
+		return "insert"
+	case OpGet:
+		return "get"
+	case OpRemove:
+		return "remove"
+	default:
+		return fmt.Sprintf("Op(%d)", uint8(o))
+	}
+}
+
+// Record is one recorded operation.
+type Record[K cmp.Ordered] struct {
+	Op  Op
+	Key K
+	// ValueSize is the size in bytes of the inserted value, 0 for other operations.
+	ValueSize int
+	// Goroutine identifies the goroutine that issued the operation.
+	Goroutine uint64
+	// Time is the start of the operation, relative to the start of the recording.
+	Time time.Duration
+}
+
+func keyKind[K cmp.Ordered]() reflect.Kind {
+	var k K
+	return reflect.TypeOf(k).Kind()
+}
+
+func writeHeader[K cmp.Ordered](w *bufio.Writer) error {
+	_, err := w.Write([]byte{magic[0], magic[1], magic[2], magic[3], version, byte(keyKind[K]())})
+	return err
+}
+
+func readHeader[K cmp.Ordered](r *bufio.Reader) error {
+	var h [6]byte
+	if _, err := io.ReadFull(r, h[:]); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return ErrBadMagic
+		}
+		return err
+	}
+	if string(h[:4]) != magic {
+		return ErrBadMagic
+	}
+	if h[4] != version {
+		return ErrBadVersion
+	}
+	if reflect.Kind(h[5]) != keyKind[K]() {
+		return fmt.Errorf("%w: trace has %v keys, want %v", ErrKeyKind, reflect.Kind(h[5]), keyKind[K]())
+	}
+	return nil
+}
+
+// appendKey appends the encoding of k, based on the kind of K like util.GetHasher.
+func appendKey[K cmp.Ordered](b []byte, k K) []byte {
+	v := reflect.ValueOf(k)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return binary.AppendVarint(b, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return binary.AppendUvarint(b, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return binary.LittleEndian.AppendUint64(b, math.Float64bits(v.Float()))
+	default: // reflect.String
+		s := v.String()
+		b = binary.AppendUvarint(b, uint64(len(s)))
+		return append(b, s...)
+	}
+}
+
+func readKey[K cmp.Ordered](r *bufio.Reader) (K, error) {
+	var k K
+	v := reflect.ValueOf(&k).Elem()
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := binary.ReadVarint(r)
+		v.SetInt(n)
+		return k, err
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := binary.ReadUvarint(r)
+		v.SetUint(n)
+		return k, err
+	case reflect.Float32, reflect.Float64:
+		var b [8]byte
+		_, err := io.ReadFull(r, b[:])
+		v.SetFloat(math.Float64frombits(binary.LittleEndian.Uint64(b[:])))
+		return k, err
+	default:
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return k, err
+		}
+		if n > maxKeySize {
+			return k, fmt.Errorf("%w: %d bytes", ErrKeyTooLong, n)
+		}
+		b := make([]byte, n)
+		_, err = io.ReadFull(r, b)
+		v.SetString(string(b))
+		return k, err
+	}
+}
+
+// Reader decodes the records of a trace.
+type Reader[K cmp.Ordered] struct {
+	r    *bufio.Reader
+	last time.Duration
+}
+
+// NewReader checks the header of the trace read from r.
+func NewReader[K cmp.Ordered](r io.Reader) (*Reader[K], error) {
+	br := bufio.NewReader(r)
+	if err := readHeader[K](br); err != nil {
+		return nil, err
+	}
+	return &Reader[K]{r: br}, nil
+}
+
+// Next returns the next record, or io.EOF after the last one.
+func (r *Reader[K]) Next() (Record[K], error) {
+	var rec Record[K]
+	op, err := r.r.ReadByte()
+	if err != nil {
+		return rec, err
+	}
+	rec.Op = Op(op)
+	if rec.Op < OpInsert || rec.Op > OpRemove {
+		return rec, fmt.Errorf("trace: unknown op %d", op)
+	}
+	if rec.Goroutine, err = binary.ReadUvarint(r.r); err != nil {
+		return rec, unexpected(err)
+	}
+	delta, err := binary.ReadVarint(r.r)
+	if err != nil {
+		return rec, unexpected(err)
+	}
+	r.last += time.Duration(delta)
+	rec.Time = r.last
+	if rec.Key, err = readKey[K](r.r); err != nil {
+		return rec, unexpected(err)
+	}
+	if rec.Op == OpInsert {
+		size, err := binary.ReadUvarint(r.r)
+		if err != nil {
+			return rec, unexpected(err)
+		}
+		if size > maxValueSize {
+			return rec, fmt.Errorf("%w: %d bytes", ErrValueTooLong, size)
+		}
+		rec.ValueSize = int(size)
+	}
+	return rec, nil
+}
+
+// unexpected turns io.EOF in the middle of a record into io.ErrUnexpectedEOF.
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,137 @@
+package trace
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"io"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/crrow/reona/util"
+)
+
+// Store is the keyed surface a Recorder wraps, linkedlist.Map implements it.
+type Store[K cmp.Ordered, V any] interface {
+	Insert(k K, v V)
+	Get(k K) (*V, bool)
+	Remove(k K) bool
+}
+
+// Recorder is a Store that forwards every operation to the wrapped Store and logs it.
+//
+// Records are appended under a mutex, so recording serializes the logging part of
+// concurrent operations; it is meant for reproducing problems, not for production speed.
+type Recorder[K cmp.Ordered, V any] struct {
+	store     Store[K, V]
+	valueSize func(V) int
+	start     time.Time
+
+	mtx  sync.Mutex
+	w    *bufio.Writer
+	last time.Duration
+	buf  []byte
+	err  error
+}
+
+// NewRecorder writes the trace header to w and returns a recorder wrapping store.
+func NewRecorder[K cmp.Ordered, V any](store Store[K, V], w io.Writer, opts ...util.Option[Recorder[K, V]]) (*Recorder[K, V], error) {
+	r := &Recorder[K, V]{
+		store:     store,
+		valueSize: defaultValueSize[V],
+		w:         bufio.NewWriter(w),
+	}
+	util.ApplyOptions[Recorder[K, V]](r, opts...)
+	if err := writeHeader[K](r.w); err != nil {
+		return nil, err
+	}
+	r.start = time.Now()
+	return r, nil
+}
+
+// WithValueSize overrides how the size of inserted values is computed.
+func WithValueSize[K cmp.Ordered, V any](size func(V) int) util.Option[Recorder[K, V]] {
+	return util.OptionFunc[Recorder[K, V]](func(r *Recorder[K, V]) {
+		r.valueSize = size
+	})
+}
+
+// defaultValueSize is the length of strings and byte slices and the shallow size of anything else.
+func defaultValueSize[V any](v V) int {
+	switch v := any(v).(type) {
+	case string:
+		return len(v)
+	case []byte:
+		return len(v)
+	default:
+		return int(unsafe.Sizeof(v))
+	}
+}
+
+func (r *Recorder[K, V]) Insert(k K, v V) {
+	begin := time.Since(r.start)
+	r.store.Insert(k, v)
+	r.record(OpInsert, k, r.valueSize(v), begin)
+}
+
+func (r *Recorder[K, V]) Get(k K) (*V, bool) {
+	begin := time.Since(r.start)
+	v, ok := r.store.Get(k)
+	r.record(OpGet, k, 0, begin)
+	return v, ok
+}
+
+func (r *Recorder[K, V]) Remove(k K) bool {
+	begin := time.Since(r.start)
+	ok := r.store.Remove(k)
+	r.record(OpRemove, k, 0, begin)
+	return ok
+}
+
+func (r *Recorder[K, V]) record(op Op, k K, size int, at time.Duration) {
+	g := goid()
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.err != nil {
+		return
+	}
+	b := append(r.buf[:0], byte(op))
+	b = binary.AppendUvarint(b, g)
+	b = binary.AppendVarint(b, int64(at-r.last))
+	b = appendKey(b, k)
+	if op == OpInsert {
+		b = binary.AppendUvarint(b, uint64(size))
+	}
+	r.last = at
+	r.buf = b
+	_, r.err = r.w.Write(b)
+}
+
+// Close flushes buffered records and returns the first write error, if any.
+// It does not close the underlying writer.
+func (r *Recorder[K, V]) Close() error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.err = r.w.Flush()
+	return r.err
+}
+
+// goid returns the id of the calling goroutine, parsed from the "goroutine N [...]"
+// header of its stack trace; go does not expose it in any cheaper way.
+func goid() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
@@ -0,0 +1,120 @@
+package trace
+
+import (
+	"cmp"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/crrow/reona/util"
+)
+
+// Target receives the operations of a replayed trace.
+type Target[K cmp.Ordered] interface {
+	Apply(r Record[K])
+}
+
+// TargetFunc adapts a function to a Target.
+type TargetFunc[K cmp.Ordered] func(r Record[K])
+
+func (fn TargetFunc[K]) Apply(r Record[K]) {
+	fn(r)
+}
+
+// StoreTarget replays records against s, inserting values built by value from the
+// recorded value size.
+func StoreTarget[K cmp.Ordered, V any](s Store[K, V], value func(size int) V) Target[K] {
+	return TargetFunc[K](func(r Record[K]) {
+		switch r.Op {
+		case OpInsert:
+			s.Insert(r.Key, value(r.ValueSize))
+		case OpGet:
+			s.Get(r.Key)
+		case OpRemove:
+			s.Remove(r.Key)
+		}
+	})
+}
+
+// ReplayConfig holds the options of Replay.
+type ReplayConfig struct {
+	concurrent bool
+	timed      bool
+}
+
+// WithConcurrency replays the records of every recorded goroutine on a goroutine of its
+// own, keeping their order within each goroutine. Without it all records are replayed
+// one by one, ordered by their start time.
+func WithConcurrency() util.Option[ReplayConfig] {
+	return util.OptionFunc[ReplayConfig](func(c *ReplayConfig) {
+		c.concurrent = true
+	})
+}
+
+// WithTiming waits before every record until as much time has passed since the start of
+// the replay as had passed since the start of the recording, instead of replaying as
+// fast as possible.
+func WithTiming() util.Option[ReplayConfig] {
+	return util.OptionFunc[ReplayConfig](func(c *ReplayConfig) {
+		c.timed = true
+	})
+}
+
+// Replay reads the whole trace from r and feeds it to t.
+func Replay[K cmp.Ordered](r io.Reader, t Target[K], opts ...util.Option[ReplayConfig]) error {
+	var cfg ReplayConfig
+	util.ApplyOptions(&cfg, opts...)
+
+	tr, err := NewReader[K](r)
+	if err != nil {
+		return err
+	}
+	var records []Record[K]
+	for {
+		rec, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		records = append(records, rec)
+	}
+	// records are written as operations finish, replay them in the order they started
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Time < records[j].Time })
+
+	start := time.Now()
+	apply := func(rec Record[K]) {
+		if cfg.timed {
+			if d := rec.Time - time.Since(start); d > 0 {
+				time.Sleep(d)
+			}
+		}
+		t.Apply(rec)
+	}
+
+	if !cfg.concurrent {
+		for _, rec := range records {
+			apply(rec)
+		}
+		return nil
+	}
+
+	byGoroutine := map[uint64][]Record[K]{}
+	for _, rec := range records {
+		byGoroutine[rec.Goroutine] = append(byGoroutine[rec.Goroutine], rec)
+	}
+	var wg sync.WaitGroup
+	wg.Add(len(byGoroutine))
+	for _, recs := range byGoroutine {
+		go func(recs []Record[K]) {
+			defer wg.Done()
+			for _, rec := range recs {
+				apply(rec)
+			}
+		}(recs)
+	}
+	wg.Wait()
+	return nil
+}
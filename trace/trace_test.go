@@ -0,0 +1,148 @@
+package trace
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/crrow/reona/linkedlist"
+	"github.com/crrow/reona/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func newMap() *linkedlist.Map[string, []byte] {
+	return linkedlist.NewMap[string, []byte](linkedlist.WithCapacity[string, []byte](8))
+}
+
+func TestRecordAndRead(t *testing.T) {
+	var buf bytes.Buffer
+	rec, err := NewRecorder[string, []byte](newMap(), &buf)
+	assert.NoError(t, err)
+
+	rec.Insert("hello", make([]byte, 42))
+	v, ok := rec.Get("hello")
+	assert.True(t, ok)
+	assert.Len(t, *v, 42)
+	assert.True(t, rec.Remove("hello"))
+	assert.False(t, rec.Remove("hello"))
+	assert.NoError(t, rec.Close())
+
+	r, err := NewReader[string](&buf)
+	assert.NoError(t, err)
+	var got []Record[string]
+	for {
+		rec, err := r.Next()
+		if err != nil {
+			break
+		}
+		got = append(got, rec)
+	}
+	assert.Len(t, got, 4)
+	assert.Equal(t, []Op{OpInsert, OpGet, OpRemove, OpRemove},
+		[]Op{got[0].Op, got[1].Op, got[2].Op, got[3].Op})
+	assert.Equal(t, 42, got[0].ValueSize)
+	for i, rec := range got {
+		assert.Equal(t, "hello", rec.Key)
+		assert.Equal(t, goid(), rec.Goroutine)
+		if i > 0 {
+			assert.GreaterOrEqual(t, rec.Time, got[i-1].Time)
+		}
+	}
+}
+
+func TestReaderRejects(t *testing.T) {
+	_, err := NewReader[int](bytes.NewReader([]byte("nope")))
+	assert.ErrorIs(t, err, ErrBadMagic)
+
+	var buf bytes.Buffer
+	rec, err := NewRecorder[string, []byte](newMap(), &buf)
+	assert.NoError(t, err)
+	rec.Insert("k", nil)
+	assert.NoError(t, rec.Close())
+
+	_, err = NewReader[int](bytes.NewReader(buf.Bytes()))
+	assert.ErrorIs(t, err, ErrKeyKind)
+
+	r, err := NewReader[string](bytes.NewReader(buf.Bytes()[:buf.Len()-1]))
+	assert.NoError(t, err)
+	_, err = r.Next()
+	assert.Error(t, err)
+
+	// a corrupt key length must not be allocated
+	buf.Reset()
+	rec, err = NewRecorder[string, []byte](newMap(), &buf)
+	assert.NoError(t, err)
+	assert.NoError(t, rec.Close())
+	buf.Write([]byte{byte(OpGet), 0, 0})
+	buf.Write(binary.AppendUvarint(nil, 1<<62))
+	r, err = NewReader[string](&buf)
+	assert.NoError(t, err)
+	_, err = r.Next()
+	assert.ErrorIs(t, err, ErrKeyTooLong)
+
+	// neither must a corrupt value size reach the replay target
+	for _, size := range []uint64{maxValueSize + 1, 1 << 63} {
+		buf.Reset()
+		rec, err = NewRecorder[string, []byte](newMap(), &buf)
+		assert.NoError(t, err)
+		assert.NoError(t, rec.Close())
+		buf.Write([]byte{byte(OpInsert), 0, 0})
+		buf.Write(appendKey(nil, "k"))
+		buf.Write(binary.AppendUvarint(nil, size))
+		r, err = NewReader[string](&buf)
+		assert.NoError(t, err)
+		_, err = r.Next()
+		assert.ErrorIs(t, err, ErrValueTooLong, size)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	var buf bytes.Buffer
+	m := linkedlist.NewMap[int, string](linkedlist.WithCapacity[int, string](8))
+	rec, err := NewRecorder[int, string](m, &buf)
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				rec.Insert(g*100+i, "value")
+				if i%5 == 0 {
+					rec.Remove(g*100 + i)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	assert.NoError(t, rec.Close())
+
+	for _, opts := range [][]util.Option[ReplayConfig]{nil, {WithConcurrency()}} {
+		replayed := linkedlist.NewMap[int, string](linkedlist.WithCapacity[int, string](8))
+		var goroutines sync.Map
+		var n atomic.Int64
+		target := StoreTarget[int, string](replayed, func(size int) string { return string(make([]byte, size)) })
+		err := Replay[int](bytes.NewReader(buf.Bytes()), TargetFunc[int](func(r Record[int]) {
+			goroutines.Store(r.Goroutine, true)
+			n.Add(1)
+			target.Apply(r)
+		}), opts...)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 4*60, n.Load())
+		assert.Equal(t, m.Len(), replayed.Len())
+		for k := 0; k < 400; k++ {
+			_, want := m.Get(k)
+			v, ok := replayed.Get(k)
+			assert.Equal(t, want, ok, "key %d", k)
+			if ok {
+				assert.Len(t, *v, int(defaultValueSize("value")))
+			}
+		}
+		count := 0
+		goroutines.Range(func(any, any) bool { count++; return true })
+		assert.Equal(t, 4, count)
+	}
+}
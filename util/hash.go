@@ -188,17 +188,10 @@ var xxHashQword = func(key uint64) uintptr {
 }
 
 var xxHashString = func(key string) uintptr {
-	sh := (*reflect.StringHeader)(unsafe.Pointer(&key))
-	bh := reflect.SliceHeader{
-		Data: sh.Data,
-		Len:  sh.Len,
-		Cap:  sh.Len, // cap needs to be set, otherwise xxhash fails on ARM Macs
-	}
-
-	b := *(*[]byte)(unsafe.Pointer(&bh))
+	b := unsafe.Slice(unsafe.StringData(key), len(key))
 	var h uint64
 
-	if sh.Len >= 32 {
+	if len(key) >= 32 {
 		v1 := prime1v + prime2
 		v2 := prime2
 		v3 := uint64(0)
@@ -219,7 +212,7 @@ var xxHashString = func(key string) uintptr {
 		h = prime5
 	}
 
-	h += uint64(sh.Len)
+	h += uint64(len(key))
 
 	i, end := 0, len(b)
 	for ; i+8 <= end; i += 8 {